@@ -0,0 +1,14 @@
+package actors
+
+// Light reaching every surface equally from all directions, raising the
+// darkest shade in the scene
+type AmbientLight struct {
+	Intensity float64 //Effect of the light 0-1
+
+	// RGB color of the light between 0-1, nil for white
+	Color *[3]float64
+}
+
+func (l *AmbientLight) Illuminate(point []float64) Incident {
+	return Incident{Amount: 5 * min(max(l.Intensity, 0), 1), Color: l.Color}
+}
@@ -0,0 +1,46 @@
+package actors
+
+import "math"
+
+// Light from far away shining the same way on the whole scene, like the sun
+type DirectionalLight struct {
+	// Direction the light travels in
+	DirX float64
+	DirY float64
+	DirZ float64
+
+	Intensity float64 //Effect of the light 0-1
+
+	// RGB color of the light between 0-1, nil for white
+	Color *[3]float64
+
+	// Objects block the light, casting shadows onto those behind them
+	Shadows bool
+}
+
+func (l *DirectionalLight) CastsShadows() bool {
+	return l.Shadows
+}
+
+// Shadows are drawn along the light over the whole scene
+func (l *DirectionalLight) ShadowView() ShadowView {
+	return ShadowView{
+		Projection: ShadowParallel,
+		Dir:        []float64{l.DirX, l.DirY, l.DirZ},
+		Color:      l.Color,
+	}
+}
+
+// Every point gets the same light from the same direction
+func (l *DirectionalLight) Illuminate(point []float64) Incident {
+	d := math.Sqrt(l.DirX*l.DirX + l.DirY*l.DirY + l.DirZ*l.DirZ)
+	if d == 0 {
+		return Incident{}
+	}
+
+	return Incident{
+		Dir:    []float64{-l.DirX / d, -l.DirY / d, -l.DirZ / d},
+		Amount: 5 * min(max(l.Intensity, 0), 1),
+		Color:  l.Color,
+	}
+}
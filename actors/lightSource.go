@@ -0,0 +1,54 @@
+package actors
+
+// Light reaching a point in the scene
+type Incident struct {
+	// Unit direction from the point towards the light, nil if the light comes
+	// from every direction
+	Dir []float64
+
+	// Shades of luminance added to a surface facing the light, 0-5
+	Amount float64
+
+	// RGB color of the light between 0-1, nil for white
+	Color *[3]float64
+}
+
+// Anything which lights the scene, registered to a View with RegisterLight
+type LightSource interface {
+	// Light reaching a worldspace point, before the angle of the surface
+	Illuminate(point []float64) Incident
+}
+
+// Lights which objects can block, casting shadows. Optional for a
+// LightSource, lights without it never cast shadows
+type ShadowCaster interface {
+	LightSource
+
+	// Shadows are on for the light
+	CastsShadows() bool
+
+	// Where the light's shadow map looks from this frame
+	ShadowView() ShadowView
+}
+
+// How a shadow map covers the area a light reaches
+type ShadowProjection uint8
+
+const (
+	ShadowCube     ShadowProjection = iota // Out of the 6 sides of a cube around Pos, like a point light
+	ShadowCone                             // From Pos along Dir, wide enough for a cone of Angle
+	ShadowParallel                         // Along Dir over the whole scene, like the sun
+)
+
+// Where a light's shadow map looks from, in worldspace
+type ShadowView struct {
+	Projection ShadowProjection
+
+	Pos   []float64 // Position of the light, unused for parallel maps
+	Dir   []float64 // Direction the light travels, unused for cube maps
+	Angle float64   // Degrees from the center of a cone to its edge
+	Far   float64   // How far the light reaches, unused for parallel maps
+
+	// RGB color of the light between 0-1, nil for white
+	Color *[3]float64
+}
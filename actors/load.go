@@ -0,0 +1,57 @@
+package actors
+
+import "go3d/utils"
+
+// Create an object from an .obj file, keeping any vertex normals it has for
+// smooth shading. Faces with a material from the file's .mtl libraries use
+// it in place of the object's color, and faces without one, or whose library
+// is missing, keep the object's color
+func LoadObject(path string, objX float64, objY float64, objZ float64, scale float64, color string) *Object {
+	objTris := utils.ParseObjTriangles(path)
+
+	triangles := make([][][]float64, len(objTris))
+	for i, t := range objTris {
+		triangles[i] = t.Verts
+	}
+
+	o := CreateObject(triangles, objX, objY, objZ, scale, color)
+
+	// Faces sharing a material share the converted one too, as do materials
+	// sharing a texture
+	materials := map[*utils.ObjMaterial]*Material{}
+	textures := map[string]*utils.Texture{}
+	for i, t := range objTris {
+		o.Tris[i].Normals = t.Normals
+		o.Tris[i].UVs = t.UVs
+
+		if t.Material == nil {
+			continue
+		}
+		if _, ok := materials[t.Material]; !ok {
+			materials[t.Material] = newMaterial(t.Material, textures)
+		}
+		o.Tris[i].Material = materials[t.Material]
+	}
+
+	return o
+}
+
+// Convert a material parsed from an .mtl file, loading its texture if it
+// has one. Textures which can't be loaded are left out
+func newMaterial(m *utils.ObjMaterial, textures map[string]*utils.Texture) *Material {
+	if m.DiffuseMap != "" {
+		if _, ok := textures[m.DiffuseMap]; !ok {
+			textures[m.DiffuseMap], _ = utils.LoadTexture(m.DiffuseMap)
+		}
+	}
+
+	return &Material{
+		Name:         m.Name,
+		Diffuse:      m.Diffuse,
+		Texture:      textures[m.DiffuseMap],
+		Ambient:      m.Ambient,
+		Specular:     m.Specular,
+		Shininess:    m.Shininess,
+		Transparency: 1 - m.Opacity,
+	}
+}
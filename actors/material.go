@@ -0,0 +1,43 @@
+package actors
+
+import "go3d/utils"
+
+// Surface of a triangle, such as one loaded from an .mtl file, overriding
+// its object's color and highlights. Colors are RGB between 0-1, nil to keep
+// the object's
+type Material struct {
+	Name string
+
+	Diffuse  *[3]float64    // Base color of the surface
+	Texture  *utils.Texture // Image mapped over the surface by its UVs, multiplied by Diffuse
+	Ambient  *[3]float64    // Share of each color of ambient light reflected
+	Specular *[3]float64    // Highlights, their brightest channel sets the strength
+
+	Shininess float64 // Higher is a smaller, sharper highlight, 0 keeps the object's
+
+	// 0 is opaque, higher lets the faces behind show through up to 1 where
+	// the surface is invisible
+	Transparency float64
+}
+
+// Base color of the surface as 0-255 RGB, nil without a diffuse color
+func (m *Material) RGB() *[3]uint8 {
+	if m == nil || m.Diffuse == nil {
+		return nil
+	}
+
+	var rgb [3]uint8
+	for i, c := range m.Diffuse {
+		rgb[i] = uint8(min(max(c, 0), 1)*255 + .5)
+	}
+	return &rgb
+}
+
+// Share of the surface's own color in each pixel it covers, 1 for opaque
+// surfaces
+func (m *Material) Opacity() float64 {
+	if m == nil {
+		return 1
+	}
+	return 1 - min(max(m.Transparency, 0), 1)
+}
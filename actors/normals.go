@@ -0,0 +1,57 @@
+package actors
+
+import (
+	"go3d/utils"
+	"math"
+)
+
+// Replace the vertex normals of every triangle with the average normal of the
+// faces sharing each vert. Faces meeting at more than creaseAngle degrees
+// keep a hard edge between them
+func (o *Object) SmoothNormals(creaseAngle float64) {
+	// Verts are matched by position, as faces don't always share vert slices
+	type key [3]float64
+
+	// Unnormalized normals are weighted by face area, so small slivers don't
+	// bend the result
+	areaNormals := make([][]float64, len(o.Tris))
+	unitNormals := make([][]float64, len(o.Tris))
+	faces := map[key][]int{}
+
+	for i, t := range o.Tris {
+		a, b, c := t.Verts[0], t.Verts[1], t.Verts[2]
+		areaNormals[i] = utils.Cross(utils.SubVec(b, a), utils.SubVec(c, a))
+		unitNormals[i] = utils.Normalize(areaNormals[i])
+
+		for _, vert := range t.Verts {
+			k := key{vert[0], vert[1], vert[2]}
+			faces[k] = append(faces[k], i)
+		}
+	}
+
+	minCos := math.Cos(utils.DegToRad(creaseAngle))
+
+	for i, t := range o.Tris {
+		normals := make([][]float64, len(t.Verts))
+
+		for j, vert := range t.Verts {
+			sum := []float64{0, 0, 0}
+			for _, f := range faces[key{vert[0], vert[1], vert[2]}] {
+				// Only blend with faces on the same side of a crease
+				if f != i && utils.Dot(unitNormals[i], unitNormals[f]) < minCos {
+					continue
+				}
+				sum[0] += areaNormals[f][0]
+				sum[1] += areaNormals[f][1]
+				sum[2] += areaNormals[f][2]
+			}
+			// Opposite faces can cancel out, fall back to the face itself
+			if utils.Length(sum) == 0 {
+				sum = unitNormals[i]
+			}
+			normals[j] = utils.Normalize(sum)
+		}
+
+		o.Tris[i].Normals = normals
+	}
+}
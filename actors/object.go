@@ -1,5 +1,24 @@
 package actors
 
+// Which faces of an object are skipped when rendering, based on the winding of
+// each triangle as seen by the camera
+type CullMode uint8
+
+const (
+	CullDefault CullMode = iota // Use the cull mode of the View
+	CullNone                    // Draw both sides of every face
+	CullBack                    // Skip faces pointing away from the camera
+	CullFront                   // Skip faces pointing towards the camera
+)
+
+// How lighting is spread across the faces of an object
+type ShadeMode uint8
+
+const (
+	ShadeFlat   ShadeMode = iota // Each face is lit once at its center
+	ShadeSmooth                  // Each vert is lit and blended across the face
+)
+
 type Object struct {
 	Tris  []Triangle
 	ObjX  float64
@@ -8,7 +27,28 @@ type Object struct {
 	Rot   []float64
 	Color string
 
+	// Exact base color for truecolor output, overriding Color if set
+	RGB *[3]uint8
+
 	Scale float64
+
+	// Override for the View's cull mode, for open meshes or inside out models
+	Cull CullMode
+
+	// Smooth shading uses the vertex normals of each triangle, see
+	// SmoothNormals for models without them
+	Shading ShadeMode
+
+	// Whether the object blocks light from shadow casting lights, and
+	// whether shadows are drawn on it
+	CastsShadows    bool
+	ReceivesShadows bool
+
+	// Strength of the highlights reflected towards the camera 0-1, 0 for a
+	// dull surface. Higher shininess gives smaller, sharper highlights like
+	// polished metal
+	Specular  float64
+	Shininess float64
 }
 
 // Constructor that associates triangles with the object
@@ -22,6 +62,11 @@ func CreateObject(triangles [][][]float64, objX float64, objY float64, objZ floa
 		ObjZ:  objZ,
 		Rot:   []float64{0, 0, 0},
 		Color: color,
+
+		CastsShadows:    true,
+		ReceivesShadows: true,
+
+		Shininess: 32,
 	}
 	// Make triangles out of the coordinates
 	for _, t := range triangles {
@@ -1,5 +1,8 @@
 package actors
 
+import "math"
+
+// Light shining in every direction from a point, fading out with distance
 type Light struct {
 	LightX float64
 	LightY float64
@@ -7,6 +10,12 @@ type Light struct {
 
 	Falloff   float64 //How far the light reaches objects
 	Intensity float64 //Maximum effect of the light 0-1
+
+	// RGB color of the light between 0-1, nil for white
+	Color *[3]float64
+
+	// Objects block the light, casting shadows onto those behind them
+	Shadows bool
 }
 
 func (l *Light) Translate(dx float64, dy float64, dz float64) {
@@ -14,3 +23,48 @@ func (l *Light) Translate(dx float64, dy float64, dz float64) {
 	l.LightY += dy
 	l.LightZ += dz
 }
+
+func (l *Light) CastsShadows() bool {
+	return l.Shadows
+}
+
+// Shadows are drawn out of a cube around the light
+func (l *Light) ShadowView() ShadowView {
+	return ShadowView{
+		Projection: ShadowCube,
+		Pos:        []float64{l.LightX, l.LightY, l.LightZ},
+		Far:        l.Falloff,
+		Color:      l.Color,
+	}
+}
+
+// Light fades linearly from the light to Falloff, reaching 0 sooner for lower
+// intensities
+func (l *Light) Illuminate(point []float64) Incident {
+	dx := l.LightX - point[0]
+	dy := l.LightY - point[1]
+	dz := l.LightZ - point[2]
+	d := math.Sqrt(dx*dx + dy*dy + dz*dz)
+
+	// Check if point is within the lights falloff
+	if d > l.Falloff {
+		return Incident{}
+	}
+
+	lightFactor := 5 - (5/l.Intensity)*(d/l.Falloff)
+
+	// Bound the light between 0-5
+	lightFactor = max(0, lightFactor)
+	lightFactor = min(5, lightFactor)
+
+	// A point on the light itself is lit from every side
+	if d == 0 {
+		return Incident{Amount: lightFactor, Color: l.Color}
+	}
+
+	return Incident{
+		Dir:    []float64{dx / d, dy / d, dz / d},
+		Amount: lightFactor,
+		Color:  l.Color,
+	}
+}
@@ -0,0 +1,127 @@
+package actors
+
+import (
+	"go3d/utils"
+	"math"
+)
+
+// Light shining in a cone from a point, fading out with distance and towards
+// the edge of the cone. Attach it to an Object to move and turn with it
+type SpotLight struct {
+	// Position, in the parent's space if attached
+	LightX float64
+	LightY float64
+	LightZ float64
+
+	// Direction the cone points, in the parent's space if attached
+	DirX float64
+	DirY float64
+	DirZ float64
+
+	// Angles from the center of the cone in degrees. Full brightness within
+	// the inner angle, fading to nothing at the outer angle
+	InnerAngle float64
+	OuterAngle float64
+
+	Falloff   float64 //How far the light reaches objects
+	Intensity float64 //Maximum effect of the light 0-1
+
+	// RGB color of the light between 0-1, nil for white
+	Color *[3]float64
+
+	// Objects block the light, casting shadows onto those behind them
+	Shadows bool
+
+	// Object the light moves and rotates with, nil for a fixed light
+	Parent *Object
+}
+
+// Move and rotate the light with an object. The light's position and
+// direction become relative to the object, in world units
+func (l *SpotLight) Attach(o *Object) {
+	l.Parent = o
+}
+
+func (l *SpotLight) Translate(dx float64, dy float64, dz float64) {
+	l.LightX += dx
+	l.LightY += dy
+	l.LightZ += dz
+}
+
+// Worldspace position of the light
+func (l *SpotLight) Position() []float64 {
+	pos := []float64{l.LightX, l.LightY, l.LightZ}
+	if l.Parent == nil {
+		return pos
+	}
+
+	// Same as the object's world transform, without its scale
+	return utils.ApplyWorldMatrix(pos, l.Parent.ObjX, l.Parent.ObjY, l.Parent.ObjZ, 1, l.Parent.Rot)
+}
+
+// Worldspace unit direction of the light
+func (l *SpotLight) Direction() []float64 {
+	dir := []float64{l.DirX, l.DirY, l.DirZ}
+	if l.Parent != nil {
+		dir = utils.RotateVecXYZ(dir, l.Parent.Rot)
+	}
+	return utils.Normalize(dir)
+}
+
+func (l *SpotLight) CastsShadows() bool {
+	return l.Shadows
+}
+
+// Shadows are drawn down the light's cone
+func (l *SpotLight) ShadowView() ShadowView {
+	return ShadowView{
+		Projection: ShadowCone,
+		Pos:        l.Position(),
+		Dir:        l.Direction(),
+		Angle:      max(l.OuterAngle, l.InnerAngle),
+		Far:        l.Falloff,
+		Color:      l.Color,
+	}
+}
+
+// Light fades with distance like a point light, and by the angle from the
+// center of the cone
+func (l *SpotLight) Illuminate(point []float64) Incident {
+	pos := l.Position()
+	toLight := utils.SubVec(pos, point)
+	d := utils.Length(toLight)
+
+	// Check if point is within the lights falloff
+	if d > l.Falloff || d == 0 {
+		return Incident{}
+	}
+
+	// Cosine of the angle between the cone's center and the point
+	toLight = []float64{toLight[0] / d, toLight[1] / d, toLight[2] / d}
+	cosAngle := -utils.Dot(toLight, l.Direction())
+
+	cosInner := math.Cos(utils.DegToRad(l.InnerAngle))
+	cosOuter := math.Cos(utils.DegToRad(max(l.OuterAngle, l.InnerAngle)))
+	if cosAngle <= cosOuter {
+		return Incident{}
+	}
+
+	// Smoothly fade between the inner and outer angles
+	cone := 1.0
+	if cosAngle < cosInner {
+		t := (cosAngle - cosOuter) / (cosInner - cosOuter)
+		cone = t * t * (3 - 2*t)
+	}
+
+	lightFactor := 5 - (5/l.Intensity)*(d/l.Falloff)
+
+	// Bound the light between 0-5
+	lightFactor = max(0, lightFactor)
+	lightFactor = min(5, lightFactor)
+
+	return Incident{
+		Dir:    toLight,
+		Amount: lightFactor * cone,
+		Color:  l.Color,
+	}
+}
@@ -3,6 +3,16 @@ package actors
 type Triangle struct {
 	Verts  [][]float64
 	ObjRef *Object
+
+	// Object space unit normal of each vert for smooth shading, nil to use
+	// the face normal
+	Normals [][]float64
+
+	// Texture coordinates of each vert, nil if the triangle isn't textured
+	UVs [][]float64
+
+	// Surface of the triangle, nil to use the object's color
+	Material *Material
 }
 
 // Basic triangle for rendering which inherits the Actor interface
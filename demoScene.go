@@ -11,6 +11,21 @@ func createDemoSceneWithStatics() *display.View {
 
 	// Create a scene
 	scene := display.CreateView(30, .4)
+	addDemoStatics(scene)
+	return scene
+}
+
+// Add the static objects, lights, fog and starting camera of the demo to a
+// scene
+func addDemoStatics(scene *display.View) {
+
+	// Fade distant objects into the night before they reach the far clip
+	scene.Fog = display.Fog{
+		Mode:  display.FogLinear,
+		Start: 30,
+		End:   50,
+		Color: [3]uint8{28, 28, 28},
+	}
 
 	// Import models
 
@@ -36,6 +51,12 @@ func createDemoSceneWithStatics() *display.View {
 	tele2.Rotate(0, 90, 0)
 	tele3.Rotate(0, 90, 0)
 
+	// The trees' own normals are per face, replace them for smooth shading
+	for _, t := range []*actors.Object{tree, tree2} {
+		t.SmoothNormals(80)
+		t.Shading = actors.ShadeSmooth
+	}
+
 	scene.MoveCam(8, 10, -2)
 	scene.RotateCam(20, -30, 0)
 
@@ -45,8 +66,12 @@ func createDemoSceneWithStatics() *display.View {
 		LightZ:    -15,
 		Intensity: .60,
 		Falloff:   30,
+		Shadows:   true,
 	}
 
+	// Keep faces away from the lights faintly visible
+	ambient := &actors.AmbientLight{Intensity: .1}
+
 	// Register within the scene
 	scene.RegisterObject(house)
 	scene.RegisterObject(cabana)
@@ -62,5 +87,5 @@ func createDemoSceneWithStatics() *display.View {
 	scene.RegisterObject(grass4)
 
 	scene.RegisterLight(houseLight)
-	return scene
+	scene.RegisterLight(ambient)
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"go3d/actors"
+	"go3d/display"
+	"go3d/utils"
+	"math"
+	"runtime"
+	"testing"
+)
+
+// Render the demo scene once with a number of render workers
+func renderDemo(workers int) *display.View {
+	scene := display.CreateHeadlessView(160, 80, 30, .4, nil)
+	scene.Output = display.OutputTrueColor
+	scene.Workers = workers
+	addDemoStatics(scene)
+
+	scene.ClearBuffer()
+	scene.PrepBuffer()
+	return scene
+}
+
+// Splitting the frame across workers must draw exactly what a single worker
+// does
+func TestParallelRenderMatchesSingleWorker(t *testing.T) {
+	// Draw wires and verts too, and keep the debug overlay's timings out of
+	// the frame
+	debug, wire, verts := utils.Debug, utils.DrawWire, utils.DrawVerts
+	utils.Debug, utils.DrawWire, utils.DrawVerts = false, true, true
+	defer func() {
+		utils.Debug, utils.DrawWire, utils.DrawVerts = debug, wire, verts
+	}()
+
+	// At least a few workers, so the work is split even on a single core
+	workers := max(runtime.NumCPU(), 4)
+
+	single := renderDemo(1)
+	parallel := renderDemo(workers)
+
+	faces := 0
+	for y := range single.FrameBuffer {
+		for x, px := range single.FrameBuffer[y] {
+			if single.DepthBuffer[y][x] < math.MaxFloat32 {
+				faces++
+			}
+			if parallel.FrameBuffer[y][x] != px {
+				t.Fatalf("pixel (%d, %d) is %+v with %d workers, %+v with 1", x, y, parallel.FrameBuffer[y][x], workers, px)
+			}
+		}
+	}
+
+	// Make sure the scene was in view
+	if faces == 0 {
+		t.Fatal("no faces were drawn")
+	}
+}
+
+// Time a frame of the demo at the size of a 200x50 terminal, with the car
+// driving through the house light's shadows
+func BenchmarkDemoFrame(b *testing.B) {
+	debug := utils.Debug
+	utils.Debug = false
+	defer func() { utils.Debug = debug }()
+
+	scene := display.CreateHeadlessView(100, 49, 30, .4, nil)
+	scene.Output = display.OutputTrueColor
+	addDemoStatics(scene)
+
+	car := actors.LoadObject("./models/car.obj", 0, -6.5, 45, .1, "Red")
+	car.SmoothNormals(60)
+	car.Shading = actors.ShadeSmooth
+	scene.RegisterObject(car)
+
+	for i := range b.N {
+		// Start the drive over before the camera passes the ground
+		if i > 0 && i%200 == 0 {
+			scene.MoveCam(0, 10, 10)
+			car.Translate(0, 0, -30)
+		}
+
+		scene.ClearBuffer()
+		scene.MoveCam(0, -.05, -.05)
+		scene.RotateCam(-.05, -.13, 0)
+		car.Translate(0, 0, .15)
+		scene.PrepBuffer()
+		scene.DrawBuffer()
+	}
+}
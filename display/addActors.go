@@ -9,11 +9,14 @@ func (v *View) RegisterTriangle(t *actors.Triangle) {
 
 func (v *View) RegisterObject(o *actors.Object) {
 	// Objects are converted to triangles in the scene, which can be associated
-	// to a parent object for transformations
-	for _, tri := range o.Tris {
-		v.RegisterTriangle(&tri)
+	// to a parent object for transformations. Triangles point into the
+	// object, so later changes to them are rendered
+	for i := range o.Tris {
+		v.RegisterTriangle(&o.Tris[i])
 	}
 }
-func (v *View) RegisterLight(l *actors.Light) {
-	v.PointLights = append(v.PointLights, l)
+
+// Add any kind of light to the scene, such as a *actors.Light
+func (v *View) RegisterLight(l actors.LightSource) {
+	v.Lights = append(v.Lights, l)
 }
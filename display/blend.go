@@ -0,0 +1,46 @@
+package display
+
+import (
+	"go3d/utils"
+	"math"
+)
+
+// Mix two pixels, t of the way from a to b. The palette color is mixed from
+// the palette colors which would have been drawn, so a pixel barely changed
+// keeps its color. Empty pixels count as black
+func BlendPixel(a utils.Pixel, b utils.Pixel, t float64) utils.Pixel {
+	if !a.Filled {
+		a = utils.Pixel{Color: 16, Shade: 1}
+	}
+	if !b.Filled {
+		b = utils.Pixel{Color: 16, Shade: 1}
+	}
+
+	out := a
+	out.Filled = true
+
+	palette := blendRGB(utils.XtermRGB(a.Color), utils.XtermRGB(b.Color), t)
+	if palette != utils.XtermRGB(a.Color) {
+		out.Color = utils.NearestXterm(palette)
+	}
+	out.RGB = blendRGB(a.RGB, b.RGB, t)
+
+	shade := float64(a.Shade) + (float64(b.Shade)-float64(a.Shade))*t
+	out.Shade = uint8(math.Round(shade))
+
+	// Whichever pixel shows most keeps its ASCII character
+	if t >= .5 {
+		out.Glyph = b.Glyph
+	}
+
+	return out
+}
+
+// Linearly interpolate between two colors
+func blendRGB(a [3]uint8, b [3]uint8, t float64) [3]uint8 {
+	var out [3]uint8
+	for i := range a {
+		out[i] = uint8(math.Round(float64(a[i]) + (float64(b[i])-float64(a[i]))*t))
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+package display
+
+import (
+	"go3d/utils"
+	"math"
+)
+
+// How the wire frame and verts are drawn
+type WireMode uint8
+
+const (
+	WireBlock   WireMode = iota // Whole pixels
+	WireBraille                 // Braille dots, 2x4 per terminal column
+)
+
+// Dot layout of a braille character, indexed by [row][column]
+var brailleBits = [4][2]uint8{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// First braille pattern character, with no dots raised
+const brailleBase = 0x2800
+
+// Dots drawn in a single terminal column, with their color
+type BrailleCell struct {
+	Dots  uint8
+	Color utils.Pixel
+}
+
+// Size of the braille buffer, one entry for each terminal column
+func (v *View) BrailleSize() (int, int) {
+	return v.CellCols() * v.CellWidth(), v.CellRows()
+}
+
+// Number of braille dots covering one pixel horizontally and vertically
+func (v *View) DotsPerPixel() (float64, float64) {
+	x := float64(v.CellWidth() * 2)
+	if v.Cells == CellHalf {
+		return x, 2
+	}
+	return x, 4
+}
+
+// Draw the wire frame and verts of every polygon as braille dots, hiding dots
+// which are behind faces in the depth buffer
+func (v *View) DrawBrailleWire(polygons []screenPolygon) {
+	wire, vert := wirePixel(), vertPixel()
+
+	for _, p := range polygons {
+		for _, i := range p.wireEdges {
+			j := (i + 1) % len(p.screenVerts)
+
+			a := []float64{p.screenVerts[i][0], p.screenVerts[i][1], p.depthVals[i]}
+			b := []float64{p.screenVerts[j][0], p.screenVerts[j][1], p.depthVals[j]}
+			v.DrawBrailleLine(a, b, wire)
+		}
+
+		for _, point := range p.verts {
+			dx, dy := v.ScreenToDot(point[0], point[1])
+			v.SetBrailleDot(dx, dy, point[2], vert)
+		}
+	}
+}
+
+// Convert a screenspace point to the braille dot containing it
+func (v *View) ScreenToDot(x float64, y float64) (int, int) {
+	dpx, dpy := v.DotsPerPixel()
+
+	// Pixel centers sit on whole screenspace coordinates
+	return int(math.Floor((x + .5) * dpx)), int(math.Floor((y + .5) * dpy))
+}
+
+// Draw a line of braille dots between two screenspace points with Bresenhams
+// alg. Points are x, y and camera depth
+func (v *View) DrawBrailleLine(a []float64, b []float64, color utils.Pixel) {
+	x0, y0 := v.ScreenToDot(a[0], a[1])
+	x1, y1 := v.ScreenToDot(b[0], b[1])
+
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	steps := max(dx, -dy)
+	err := dx + dy
+
+	// 1/depth is linear in screenspace
+	invA, invB := 1/a[2], 1/b[2]
+
+	for i := 0; ; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		v.SetBrailleDot(x0, y0, 1/(invA+(invB-invA)*t), color)
+
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// Raise a braille dot if it is not hidden behind a face
+func (v *View) SetBrailleDot(x int, y int, depth float64, color utils.Pixel) {
+	cols, rows := v.BrailleSize()
+	if x < 0 || y < 0 || x >= cols*2 || y >= rows*4 {
+		return
+	}
+
+	// Compare against the face in the pixel under the dot, with some slack so
+	// edges are not hidden by their own face
+	dpx, dpy := v.DotsPerPixel()
+	px := min(int(float64(x)/dpx), int(v.Xpx)-1)
+	py := min(int(float64(y)/dpy), int(v.Ypx)-1)
+	if depth > v.DepthBuffer[py][px]*1.02+.05 {
+		return
+	}
+
+	c := &v.BrailleBuffer[y/4][x/2]
+	c.Dots |= brailleBits[y%4][x%2]
+	c.Color = color
+}
+
+// Glyph for a set of braille dots, blank if none are raised
+func brailleGlyph(dots uint8) string {
+	if dots == 0 {
+		return " "
+	}
+	return string(rune(brailleBase + int(dots)))
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
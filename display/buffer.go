@@ -2,7 +2,9 @@ package display
 
 import (
 	"fmt"
+	"go3d/utils"
 	"math"
+	"strconv"
 	"strings"
 )
 
@@ -11,11 +13,28 @@ var pixel = map[uint8]string{
 	1: "██",
 }
 
-// Set the FrameBuffer to empty pixels and depth buffer to max depth
+// Pre-encoded escape codes for each xterm 256 color
+var colorCodes [256]string
+
+func init() {
+	for i := range colorCodes {
+		colorCodes[i] = "\033[38;5;" + strconv.Itoa(i) + "m"
+	}
+}
+
+// Set the FrameBuffer to empty pixels, or the fog color, depth buffer to max
+// depth and remove braille dots
 func (v *View) ClearBuffer() {
+	// Fog fills the background, except in ASCII output where it would cover
+	// the screen in characters
+	var background utils.Pixel
+	if v.Fog.Mode != FogNone && v.Output != OutputASCII {
+		background = v.Fog.Pixel()
+	}
+
 	for i := range v.FrameBuffer {
 		for j := range v.FrameBuffer[i] {
-			v.FrameBuffer[i][j] = pixel[0]
+			v.FrameBuffer[i][j] = background
 		}
 	}
 	for i := range v.DepthBuffer {
@@ -23,26 +42,79 @@ func (v *View) ClearBuffer() {
 			v.DepthBuffer[i][j] = math.MaxFloat32
 		}
 	}
+	for i := range v.BrailleBuffer {
+		clear(v.BrailleBuffer[i])
+	}
 
 }
 
-// Prints buffer contents to screen
+// Prints buffer contents to screen. With DiffOutput only the cells which
+// changed since the last frame are written, otherwise the whole screen is
+// redrawn. Either way, runs of the same color share one escape code. ASCII
+// output is always written in full, one frame after another
 func (v *View) DrawBuffer() {
 
 	var sb strings.Builder
+	enc := cellEncoder{sb: &sb, mode: v.Output}
+
+	rows := v.CellRows()
+	cols := v.CellCols()
 
-	// sb.WriteString(v.Xborder)
-	for _, row := range v.FrameBuffer {
-		for _, pxl := range row {
-			sb.WriteString(pxl)
+	// Redraw everything when there is no previous frame to compare to. ASCII
+	// output can't move the cursor, so frames are always written in full
+	full := !v.DiffOutput || v.Output == OutputASCII || len(v.prevCells) != rows || (rows > 0 && len(v.prevCells[0]) != cols)
+	if full {
+		v.prevCells = make([][]cell, rows)
+		for i := range v.prevCells {
+			v.prevCells[i] = make([]cell, cols)
+		}
+		// Reset cursor to top of window
+		if v.Output != OutputASCII {
+			sb.WriteString("\033[H")
 		}
-		sb.WriteByte('\n')
 	}
-	// sb.WriteString(v.Xborder)
 
-	s := sb.String()
-	// Reset cursor to top of window
-	fmt.Print("\033[H")
-	fmt.Print(s)
+	// Where the terminal cursor is after the last write
+	curRow, curCol := 0, 0
+
+	for row := range rows {
+		for col := range cols {
+			c := v.CellAt(row, col)
+
+			if !full {
+				if c == v.prevCells[row][col] {
+					continue
+				}
+				// Jump to the start of a changed run
+				if row != curRow || col != curCol {
+					sb.WriteString(v.cursorTo(row, col))
+				}
+			}
 
+			enc.WriteCell(c)
+			v.prevCells[row][col] = c
+			curRow, curCol = row, col+1
+		}
+		if full {
+			sb.WriteByte('\n')
+			curRow, curCol = row+1, 0
+		}
+	}
+
+	fmt.Fprint(v.Out, sb.String())
+
+}
+
+// Forget the previous frame so the next DrawBuffer redraws the whole screen,
+// needed if anything else has written to the terminal
+func (v *View) InvalidateOutput() {
+	v.prevCells = nil
+}
+
+// Convert a framebuffer pixel to the characters printed to the terminal
+func EncodePixel(p utils.Pixel) string {
+	if !p.Filled {
+		return pixel[0]
+	}
+	return colorCodes[p.Color] + pixel[1]
 }
@@ -0,0 +1,87 @@
+package display
+
+import (
+	"go3d/utils"
+	"strings"
+)
+
+// How framebuffer pixels are packed into terminal character cells
+type CellMode uint8
+
+const (
+	CellFull CellMode = iota // One pixel per cell, drawn 2 columns wide
+	CellHalf                 // Two pixels stacked in a 1 column cell with half blocks
+)
+
+// Terminal cells are roughly twice as tall as they are wide
+const CellAspect = 2.0
+
+// Half block glyphs, colored with the top pixel as foreground and the bottom
+// pixel as background
+const (
+	upperHalf = "▀"
+	lowerHalf = "▄"
+	fullBlock = "█"
+)
+
+// Switch how pixels are drawn, resizing the buffers to fill the same terminal
+// area at the new resolution
+func (v *View) SetCellMode(mode CellMode) {
+	v.Cells = mode
+	v.ApplySize(v.TermCols, v.TermRows)
+}
+
+// Terminal area holding a size in pixels in the current cell mode. Half
+// block cells round an odd height up to whole cells
+func (v *View) cellsFor(width uint16, height uint16) (uint16, uint16) {
+	if v.Cells == CellHalf {
+		return width, (height + 1) / 2
+	}
+	return width * 2, height
+}
+
+// Size the pixel buffers and projection to fill an area of the terminal
+func (v *View) ApplySize(cols uint16, rows uint16) {
+	v.TermCols = cols
+	v.TermRows = rows
+
+	switch v.Cells {
+	case CellHalf:
+		v.Xpx = cols
+		v.Ypx = rows * 2
+	default:
+		v.Xpx = cols / 2
+		v.Ypx = rows
+	}
+
+	// Initialize buffers
+	v.FrameBuffer, v.DepthBuffer = utils.CreateBuffers(v.Xpx, v.Ypx)
+
+	brailleCols, brailleRows := v.BrailleSize()
+	v.BrailleBuffer = make([][]BrailleCell, brailleRows)
+	for i := range v.BrailleBuffer {
+		v.BrailleBuffer[i] = make([]BrailleCell, brailleCols)
+	}
+
+	// Calculate projection constants
+	v.CalcProjectionConstants()
+
+	// Initialize screen border
+	v.Xborder = strings.Repeat(EncodePixel(utils.ColorPixel("Blue", 4)), int(v.Xpx)+2) + "\033[0m\n"
+
+	// Anything on screen is from the old layout
+	v.InvalidateOutput()
+	v.ClearBuffer()
+}
+
+// Width over height of a single pixel on screen, in terminal column widths
+func (v *View) PixelAspect() float64 {
+	switch v.Cells {
+	case CellHalf:
+		// 1 column wide, half a row tall
+		return 1 / (CellAspect / 2)
+	default:
+		// 2 columns wide, 1 row tall
+		return 2 / CellAspect
+	}
+}
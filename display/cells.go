@@ -0,0 +1,225 @@
+package display
+
+import (
+	"go3d/utils"
+	"strconv"
+	"strings"
+)
+
+// A terminal character cell built from framebuffer pixels. Unfilled colors
+// use the terminal's default
+type cell struct {
+	glyph string
+	fg    utils.Pixel
+	bg    utils.Pixel
+}
+
+// Number of terminal rows and cells per row needed to draw the framebuffer
+func (v *View) CellRows() int {
+	if v.Cells == CellHalf {
+		return (int(v.Ypx) + 1) / 2
+	}
+	return int(v.Ypx)
+}
+
+func (v *View) CellCols() int {
+	return int(v.Xpx)
+}
+
+// Terminal columns taken by a single cell
+func (v *View) CellWidth() int {
+	if v.Cells == CellHalf {
+		return 1
+	}
+	return 2
+}
+
+// Build the terminal cell at a row and column from the framebuffer
+func (v *View) CellAt(row int, col int) cell {
+	var c cell
+	if v.Output == OutputASCII {
+		c = v.asciiCellAt(row, col)
+	} else if v.Cells == CellHalf {
+		c = v.halfCellAt(row, col)
+	} else {
+		p := v.FrameBuffer[row][col]
+		if p.Filled {
+			c = cell{glyph: pixel[1], fg: v.CellColor(p)}
+		} else {
+			c = cell{glyph: pixel[0]}
+		}
+	}
+
+	// Plain text can't hold braille, dots are drawn as wire and vert
+	// characters instead
+	if v.Wire == WireBraille {
+		if v.Output == OutputASCII {
+			return v.asciiDotCellAt(row, col, c)
+		}
+		return v.brailleCellAt(row, col, c)
+	}
+	return c
+}
+
+// Draw any braille dots in a cell over the face behind them. The face color
+// becomes the background so it still shows between the dots
+func (v *View) brailleCellAt(row int, col int, face cell) cell {
+	width := v.CellWidth()
+	dots := v.BrailleBuffer[row][col*width : (col+1)*width]
+
+	var c cell
+	for _, d := range dots {
+		if d.Dots != 0 {
+			c.fg = v.CellColor(d.Color)
+		}
+		c.glyph += brailleGlyph(d.Dots)
+	}
+
+	// No dots, keep the face as is
+	if !c.fg.Filled {
+		return face
+	}
+
+	// The face's main color becomes the background, half blocks with 2
+	// colors can only keep the top one
+	c.bg = face.fg
+	return c
+}
+
+// Build a cell from the luminance of its pixels with the ASCII ramp. Half
+// block cells use the brighter of their two pixels, or the wire or vert in
+// either of them
+func (v *View) asciiCellAt(row int, col int) cell {
+	if v.Cells == CellHalf {
+		p := v.FrameBuffer[row*2][col]
+		if row*2+1 < int(v.Ypx) {
+			bottom := v.FrameBuffer[row*2+1][col]
+			if !p.Filled || (bottom.Filled && p.Glyph == 0 && (bottom.Glyph != 0 || bottom.Shade > p.Shade)) {
+				p = bottom
+			}
+		}
+		if !p.Filled {
+			return cell{glyph: " "}
+		}
+		return cell{glyph: string(asciiChar(p))}
+	}
+
+	p := v.FrameBuffer[row][col]
+	if !p.Filled {
+		return cell{glyph: pixel[0]}
+	}
+	return cell{glyph: strings.Repeat(string(asciiChar(p)), 2)}
+}
+
+// Replace the columns of an ASCII cell holding braille dots with the wire or
+// vert character of the dots
+func (v *View) asciiDotCellAt(row int, col int, face cell) cell {
+	width := v.CellWidth()
+	dots := v.BrailleBuffer[row][col*width : (col+1)*width]
+
+	glyph := []byte(face.glyph)
+	for i, d := range dots {
+		if d.Dots != 0 && i < len(glyph) {
+			glyph[i] = d.Color.Glyph
+		}
+	}
+	return cell{glyph: string(glyph)}
+}
+
+// Build a cell from two vertically stacked pixels with half blocks
+func (v *View) halfCellAt(row int, col int) cell {
+	top := v.CellColor(v.FrameBuffer[row*2][col])
+
+	// Odd pixel heights leave the last bottom half empty
+	var bottom utils.Pixel
+	if row*2+1 < int(v.Ypx) {
+		bottom = v.CellColor(v.FrameBuffer[row*2+1][col])
+	}
+
+	switch {
+	case !top.Filled && !bottom.Filled:
+		return cell{glyph: " "}
+	case !bottom.Filled:
+		return cell{glyph: upperHalf, fg: top}
+	case !top.Filled:
+		return cell{glyph: lowerHalf, fg: bottom}
+	case top == bottom:
+		return cell{glyph: fullBlock, fg: top}
+	default:
+		return cell{glyph: upperHalf, fg: top, bg: bottom}
+	}
+}
+
+// Keep only the parts of a pixel's color used by the output mode, so cells
+// only differ when their output would
+func (v *View) CellColor(p utils.Pixel) utils.Pixel {
+	if !p.Filled {
+		return utils.Pixel{}
+	}
+	if v.Output == OutputTrueColor {
+		return utils.Pixel{Filled: true, RGB: p.RGB}
+	}
+	return utils.Pixel{Filled: true, Color: p.Color}
+}
+
+// Tracks the colors currently set in the terminal so escape codes are only
+// written when a cell's colors differ from the one before it
+type cellEncoder struct {
+	sb    *strings.Builder
+	mode  OutputMode
+	fg    utils.Pixel
+	bg    utils.Pixel
+	known bool
+}
+
+// Write a cell, changing terminal colors only when needed
+func (e *cellEncoder) WriteCell(c cell) {
+	// No colors at all in ASCII output
+	if e.mode == OutputASCII {
+		e.sb.WriteString(c.glyph)
+		return
+	}
+
+	// Foreground color is not visible on blank glyphs
+	blank := strings.TrimSpace(c.glyph) == ""
+
+	if !e.known || c.bg != e.bg {
+		e.sb.WriteString(e.colorCode(c.bg, false))
+		e.bg = c.bg
+	}
+	if !e.known || (!blank && c.fg != e.fg) {
+		e.sb.WriteString(e.colorCode(c.fg, true))
+		e.fg = c.fg
+	}
+	e.known = true
+
+	e.sb.WriteString(c.glyph)
+}
+
+// Escape code to set the foreground or background color
+func (e *cellEncoder) colorCode(p utils.Pixel, fg bool) string {
+	if !p.Filled {
+		if fg {
+			return "\033[39m"
+		}
+		return "\033[49m"
+	}
+
+	layer := "48"
+	if fg {
+		layer = "38"
+	}
+
+	if e.mode == OutputTrueColor {
+		return "\033[" + layer + ";2;" + strconv.Itoa(int(p.RGB[0])) + ";" + strconv.Itoa(int(p.RGB[1])) + ";" + strconv.Itoa(int(p.RGB[2])) + "m"
+	}
+	if fg {
+		return colorCodes[p.Color]
+	}
+	return "\033[" + layer + ";5;" + strconv.Itoa(int(p.Color)) + "m"
+}
+
+// Escape code to move the cursor to a cell, terminal positions start at 1
+func (v *View) cursorTo(row int, col int) string {
+	return "\033[" + strconv.Itoa(row+1) + ";" + strconv.Itoa(col*v.CellWidth()+1) + "H"
+}
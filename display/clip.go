@@ -0,0 +1,145 @@
+package display
+
+import "math"
+
+// Clip space planes which a polygon is clipped against, in order
+const (
+	clipNear = iota
+	clipFar
+	clipLeft
+	clipRight
+	clipBottom
+	clipTop
+	clipPlaneCount
+)
+
+// Clip a polygon in clip space (x, y, z, w) against the near/far planes and
+// the screen edges with Sutherland-Hodgman. Returns the visible part of the
+// polygon, which will have less than 3 verts if nothing is visible
+func (v *View) ClipPolygon(verts [][]float64) [][]float64 {
+	near := math.Abs(v.NearClip)
+	far := math.Abs(v.FarClip)
+
+	// Most polygons are entirely on screen, skip the work for these
+	if v.InsideFrustum(verts) {
+		return verts
+	}
+
+	for plane := range clipPlaneCount {
+		if len(verts) < 3 {
+			return nil
+		}
+		verts = clipAgainstPlane(verts, plane, near, far)
+	}
+
+	return verts
+}
+
+// Check if every vert of a polygon in clip space is within the view frustum
+func (v *View) InsideFrustum(verts [][]float64) bool {
+	near := math.Abs(v.NearClip)
+	far := math.Abs(v.FarClip)
+
+	for _, vert := range verts {
+		for plane := range clipPlaneCount {
+			if planeDistance(vert, plane, near, far) < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Check if every vert of a polygon in clip space is outside the same frustum
+// plane, in which case none of it can be visible
+func (v *View) OutsideFrustum(verts [][]float64) bool {
+	near := math.Abs(v.NearClip)
+	far := math.Abs(v.FarClip)
+
+	for plane := range clipPlaneCount {
+		outside := true
+		for _, vert := range verts {
+			if planeDistance(vert, plane, near, far) >= 0 {
+				outside = false
+				break
+			}
+		}
+		if outside {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if the edge between two clip space verts lies along a frustum plane,
+// meaning it was created by clipping rather than being part of the triangle
+func (v *View) OnClipPlane(a []float64, b []float64) bool {
+	near := math.Abs(v.NearClip)
+	far := math.Abs(v.FarClip)
+
+	// Allow for floating point error in the intersections
+	epsA := 1e-9 * max(1, math.Abs(a[3]))
+	epsB := 1e-9 * max(1, math.Abs(b[3]))
+
+	for plane := range clipPlaneCount {
+		if math.Abs(planeDistance(a, plane, near, far)) <= epsA && math.Abs(planeDistance(b, plane, near, far)) <= epsB {
+			return true
+		}
+	}
+	return false
+}
+
+// Signed distance of a clip space vert from a frustum plane, positive is inside
+func planeDistance(vert []float64, plane int, near float64, far float64) float64 {
+	x, y, w := vert[0], vert[1], vert[3]
+
+	switch plane {
+	case clipNear:
+		return w - near
+	case clipFar:
+		return far - w
+	case clipLeft:
+		return w + x
+	case clipRight:
+		return w - x
+	case clipBottom:
+		return w + y
+	case clipTop:
+		return w - y
+	}
+	return 0
+}
+
+// Clip a polygon against a single plane, adding new verts where edges cross it
+func clipAgainstPlane(verts [][]float64, plane int, near float64, far float64) [][]float64 {
+	clipped := make([][]float64, 0, len(verts)+1)
+
+	for i, cur := range verts {
+		next := verts[(i+1)%len(verts)]
+
+		dCur := planeDistance(cur, plane, near, far)
+		dNext := planeDistance(next, plane, near, far)
+
+		// Keep verts inside the plane
+		if dCur >= 0 {
+			clipped = append(clipped, cur)
+		}
+
+		// Edge crosses the plane, add the intersection
+		if (dCur >= 0) != (dNext >= 0) {
+			t := dCur / (dCur - dNext)
+			clipped = append(clipped, LerpVert(cur, next, t))
+		}
+	}
+
+	return clipped
+}
+
+// Linearly interpolate every component of two verts
+func LerpVert(a []float64, b []float64, t float64) []float64 {
+	out := make([]float64, len(a))
+	for i := range a {
+		out[i] = a[i] + (b[i]-a[i])*t
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package display
+
+import "go3d/actors"
+
+// Check if a triangle should be skipped based on which way it faces the
+// camera. .obj faces are counter clockwise, so a triangle which is counter
+// clockwise once projected is facing the camera
+func (v *View) IsCulled(clipVerts [][]float64, mode actors.CullMode) bool {
+	// Objects may defer to the View
+	if mode == actors.CullDefault {
+		mode = v.Cull
+	}
+	if mode == actors.CullNone || mode == actors.CullDefault {
+		return false
+	}
+
+	a, b, c := clipVerts[0], clipVerts[1], clipVerts[2]
+
+	// Determinant of the clip space x, y, w. The sign is the screen winding,
+	// but stays correct for verts behind the camera, so culling can happen
+	// before clipping
+	det := a[0]*(b[1]*c[3]-c[1]*b[3]) - a[1]*(b[0]*c[3]-c[0]*b[3]) + a[3]*(b[0]*c[1]-c[0]*b[1])
+
+	// Faces seen edge on have no area, skip them either way
+	if mode == actors.CullBack {
+		return det <= 0
+	}
+	return det >= 0
+}
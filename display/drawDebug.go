@@ -48,10 +48,11 @@ func (v *View) DrawDebug() {
 	v.DrawBigDebug(2, fmt.Sprintf("P FPS:   %.3f", pfps), c1)
 	v.DrawBigDebug(3, fmt.Sprintf("RL FPS:  %.3f", fps), c1)
 	v.DrawBigDebug(4, fmt.Sprintf("POLYS:   %v", len(v.Triangles)), c1)
-	v.DrawBigDebug(5, fmt.Sprintf("LIGHTS:  %v", len(v.PointLights)), c1)
-	v.DrawBigDebug(7, fmt.Sprintf("FT AVG:     %.3fms", aFt), c2)
-	v.DrawBigDebug(8, fmt.Sprintf("FT UTL AVG: %.3f%%", 100*aFt/maxFtMs), c2)
-	v.DrawBigDebug(9, fmt.Sprintf("PT FPS AVG: %.3f", 1000/aFt), c2)
+	v.DrawBigDebug(5, fmt.Sprintf("LIGHTS:  %v", len(v.Lights)), c1)
+	v.DrawBigDebug(6, fmt.Sprintf("CULLED:  %v", v.CulledTris), c1)
+	v.DrawBigDebug(8, fmt.Sprintf("FT AVG:     %.3fms", aFt), c2)
+	v.DrawBigDebug(9, fmt.Sprintf("FT UTL AVG: %.3f%%", 100*aFt/maxFtMs), c2)
+	v.DrawBigDebug(10, fmt.Sprintf("PT FPS AVG: %.3f", 1000/aFt), c2)
 
 	// Save average for next frame for memory efficient avg frametime calc
 	v.PrevFt = aFt
@@ -91,7 +92,7 @@ func (v *View) DrawBigDebug(line uint16, text string, color string) {
 
 				// Fill in pixel if appropriate
 				if bigChar[index] == 1 && pixelX < v.Xpx && pixelY < v.Ypx {
-					v.FrameBuffer[pixelY][pixelX] = utils.ColorMap[color][6]
+					v.FrameBuffer[pixelY][pixelX] = utils.ColorPixel(color, 6)
 				}
 			}
 		}
@@ -0,0 +1,134 @@
+package display
+
+import (
+	"bufio"
+	"fmt"
+	"go3d/utils"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+)
+
+// Color used for empty pixels in exported images
+var ExportBackground = color.RGBA{0, 0, 0, 255}
+
+// RGB color of a pixel as it appears in the current output mode
+func (v *View) PixelRGB(p utils.Pixel) color.RGBA {
+	if !p.Filled {
+		return ExportBackground
+	}
+
+	rgb := utils.XtermRGB(p.Color)
+	if v.Output == OutputTrueColor {
+		rgb = p.RGB
+	}
+	return color.RGBA{rgb[0], rgb[1], rgb[2], 255}
+}
+
+// Draw the current frame to an image, with each pixel scaled up to a
+// scale x scale block. Braille dots are drawn over the faces
+func (v *View) FrameImage(scale int) *image.RGBA {
+	scale = max(scale, 1)
+	img := image.NewRGBA(image.Rect(0, 0, int(v.Xpx)*scale, int(v.Ypx)*scale))
+
+	for y, row := range v.FrameBuffer {
+		for x, p := range row {
+			c := v.PixelRGB(p)
+			for dy := range scale {
+				for dx := range scale {
+					img.SetRGBA(x*scale+dx, y*scale+dy, c)
+				}
+			}
+		}
+	}
+
+	if v.Wire == WireBraille {
+		v.brailleDotRects(scale, func(r image.Rectangle, p utils.Pixel) {
+			draw.Draw(img, r, image.NewUniform(v.PixelRGB(p)), image.Point{}, draw.Src)
+		})
+	}
+
+	return img
+}
+
+// Call fill with the image region and color of each raised braille dot,
+// for an image with each pixel scaled to a scale x scale block
+func (v *View) brailleDotRects(scale int, fill func(r image.Rectangle, color utils.Pixel)) {
+	dpx, dpy := v.DotsPerPixel()
+	dotW := float64(scale) / dpx
+	dotH := float64(scale) / dpy
+
+	for row, cells := range v.BrailleBuffer {
+		for col, c := range cells {
+			if c.Dots == 0 {
+				continue
+			}
+
+			for dotRow := range 4 {
+				for dotCol := range 2 {
+					if c.Dots&brailleBits[dotRow][dotCol] == 0 {
+						continue
+					}
+
+					// Region covered by the dot, at least one image pixel
+					x0 := int(float64(col*2+dotCol) * dotW)
+					y0 := int(float64(row*4+dotRow) * dotH)
+					x1 := max(int(float64(col*2+dotCol+1)*dotW), x0+1)
+					y1 := max(int(float64(row*4+dotRow+1)*dotH), y0+1)
+
+					fill(image.Rect(x0, y0, x1, y1), c.Color)
+				}
+			}
+		}
+	}
+}
+
+// Encode the current frame as a PNG
+func (v *View) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, v.FrameImage(scale))
+}
+
+// Encode the current frame as a binary PPM (P6)
+func (v *View) WritePPM(w io.Writer, scale int) error {
+	img := v.FrameImage(scale)
+	bounds := img.Bounds()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "P6\n%d %d\n255\n", bounds.Dx(), bounds.Dy())
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			bw.Write([]byte{c.R, c.G, c.B})
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Save the current frame to a PNG file
+func (v *View) SavePNG(path string, scale int) error {
+	return saveFile(path, func(w io.Writer) error { return v.WritePNG(w, scale) })
+}
+
+// Save the current frame to a PPM file
+func (v *View) SavePPM(path string, scale int) error {
+	return saveFile(path, func(w io.Writer) error { return v.WritePPM(w, scale) })
+}
+
+// Create a file and write to it, reporting errors from both writing and closing
+func saveFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
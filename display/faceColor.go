@@ -1,54 +1,124 @@
 package display
 
 import (
+	"go3d/actors"
 	"go3d/utils"
 	"math"
 )
 
-// Returns a value between 1-10 referring to a color shade based on scene
-// lighting and camera depth
-func (v *View) CalculateFaceColor(depth float64, center []float64, falloff float64) int {
-	var baseIntensity = 1
+// Add up the light reaching a point from every light, for each color channel.
+// Light from lights with a shadow map is returned separately, by the index
+// of its map, so it can be blocked per pixel. Points on objects which don't
+// receive shadows get all of it added to the total. The triangle's object and
+// material also set the highlights of the surface, a nil triangle has none
+func (v *View) GatherLight(point []float64, normal []float64, tri *actors.Triangle) ([3]float64, []float64) {
+	var light [3]float64
+	shadowed := make([]float64, len(v.shadowMaps))
 
-	if utils.RenderLighting {
-		// 1 is the minimum luminance for a given color
+	receivesShadows := true
+	var specular, shininess float64
+	var ambient *[3]float64
+	if tri != nil {
+		receivesShadows = tri.ObjRef.ReceivesShadows
+		specular, shininess, ambient = surfaceLighting(tri)
+	}
+
+	// Direction to the camera, for highlights
+	var toCam []float64
+	if specular > 0 {
+		toCam = utils.Normalize([]float64{v.CamX - point[0], v.CamY - point[1], v.CamZ - point[2]})
+	}
 
-		// Apply scene lighting
-		for _, light := range v.PointLights {
-			// Calculate worldspace distance from light to face
-			d := math.Sqrt(math.Pow(light.LightX-center[0], 2) + math.Pow(light.LightY-center[1], 2) + math.Pow(light.LightZ-center[2], 2))
+	for _, source := range v.Lights {
+		in := source.Illuminate(point)
+		amount := in.Amount
 
-			// Check if face is within the lights falloff
-			if d <= light.Falloff {
-				lightFactor := int(5 - math.Round((5/light.Intensity)*(d/light.Falloff)))
+		// Faces turned away from the light get less of it (Lambert),
+		// light without a direction reaches every face fully
+		if in.Dir != nil {
+			lambert := utils.Dot(normal, in.Dir)
+			amount *= max(0, lambert)
+
+			// Light reflected towards the camera (Blinn-Phong), strongest
+			// when the normal is halfway between the light and the camera
+			if toCam != nil && lambert > 0 {
+				half := utils.Normalize([]float64{in.Dir[0] + toCam[0], in.Dir[1] + toCam[1], in.Dir[2] + toCam[2]})
+				amount += in.Amount * specular * math.Pow(max(0, utils.Dot(normal, half)), shininess)
+			}
+		}
+
+		if receivesShadows && amount > 0 {
+			if i, _ := v.shadowMapOf(source); i >= 0 {
+				shadowed[i] = amount
+				continue
+			}
+		}
 
-				// Bound the light between 1-5
-				lightFactor = max(0, lightFactor)
-				lightFactor = min(5, lightFactor)
-				baseIntensity += lightFactor
+		// Materials reflect only some colors of ambient light
+		color := in.Color
+		if in.Dir == nil && ambient != nil {
+			color = new([3]float64)
+			for i := range color {
+				color[i] = ambient[i]
+				if in.Color != nil {
+					color[i] *= in.Color[i]
+				}
 			}
 		}
+		addLight(&light, amount, color)
+	}
 
-		// Adjust based on camera depth
+	return light, shadowed
+}
 
-		// Reduce luminance a bit for extremely far objects Apply falloff to
-		// depth range, this is where the effect will be applied
-		depthRange := falloff * (math.Abs(v.FarClip) - math.Abs(v.NearClip))
-		minDepth := math.Abs(v.FarClip) - depthRange //Minimum depth at which depth will be applied
+// Highlight strength, shininess and ambient color of a triangle, from its
+// material where set and its object otherwise
+func surfaceLighting(tri *actors.Triangle) (float64, float64, *[3]float64) {
+	specular, shininess := tri.ObjRef.Specular, tri.ObjRef.Shininess
 
-		maxEffect := 2
+	m := tri.Material
+	if m == nil {
+		return specular, shininess, nil
+	}
+	if m.Specular != nil {
+		specular = max(m.Specular[0], m.Specular[1], m.Specular[2])
+	}
+	if m.Shininess > 0 {
+		shininess = m.Shininess
+	}
+	return specular, shininess, m.Ambient
+}
 
-		if depth >= minDepth {
-			dNorm := depth - minDepth
-			maxNorm := math.Abs(v.FarClip) - minDepth
-			baseIntensity -= int(math.Round(float64(maxEffect) * (dNorm / maxNorm)))
+// Add an amount of colored light to each channel, lights without a color are
+// white
+func addLight(light *[3]float64, amount float64, color *[3]float64) {
+	for i := range light {
+		if color != nil {
+			light[i] += amount * color[i]
+		} else {
+			light[i] += amount
 		}
+	}
+}
 
-		// Bound final luminance to colorspace
-		baseIntensity = min(baseIntensity, 10)
-		baseIntensity = max(baseIntensity, 1)
+// Turn the total light at a point into a luminance between 1-10 and the
+// color of the light
+func LightShade(light [3]float64) (float64, [3]float64) {
+	// 1 is the minimum luminance for a given color, ambient lights raise it
+	baseIntensity := 1.0
+	tint := [3]float64{1, 1, 1}
 
+	// The brightest channel sets the luminance, the rest of the light's
+	// color is kept as a tint
+	brightest := max(light[0], light[1], light[2])
+	baseIntensity += brightest
+	if brightest > 0 {
+		tint = [3]float64{light[0] / brightest, light[1] / brightest, light[2] / brightest}
 	}
-	return baseIntensity
 
+	// Bound final luminance to colorspace
+	baseIntensity = min(baseIntensity, 10)
+	baseIntensity = max(baseIntensity, 1)
+
+	return baseIntensity, tint
 }
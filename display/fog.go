@@ -0,0 +1,84 @@
+package display
+
+import (
+	"go3d/utils"
+	"math"
+)
+
+// How fog thickens with distance from the camera
+type FogMode uint8
+
+const (
+	FogNone   FogMode = iota // No fog, faces keep their color at any depth
+	FogLinear                // Fog goes from none at Start to full at End
+	FogExp                   // Fog thickens quickly near the camera, then slowly
+	FogExp2                  // Fog stays thin near the camera, then thickens quickly
+)
+
+// Distance fog, blending faces towards a color by their depth from the camera
+// so they fade out before reaching the far clip
+type Fog struct {
+	Mode FogMode
+
+	// Camera depths where linear fog begins and where it hides everything
+	Start float64
+	End   float64
+
+	// Thickness of exponential fog, around 1/Density is fully fogged for
+	// FogExp2
+	Density float64
+
+	// RGB color of the fog, black by default. Also fills the background
+	Color [3]uint8
+}
+
+// How much of a point at a camera depth is hidden by the fog, 0 is clear and
+// 1 is only fog
+func (f *Fog) Amount(depth float64) float64 {
+	var amount float64
+
+	switch f.Mode {
+	case FogLinear:
+		if f.End <= f.Start {
+			if depth >= f.Start {
+				return 1
+			}
+			return 0
+		}
+		amount = (depth - f.Start) / (f.End - f.Start)
+	case FogExp:
+		amount = 1 - math.Exp(-f.Density*depth)
+	case FogExp2:
+		amount = 1 - math.Exp(-(f.Density*depth)*(f.Density*depth))
+	}
+
+	return min(max(amount, 0), 1)
+}
+
+// The fog's color as a pixel, for filling the background
+func (f *Fog) Pixel() utils.Pixel {
+	return utils.Pixel{
+		Filled: true,
+		Color:  utils.NearestXterm(f.Color),
+		Shade:  lumaShade(f.Color),
+		RGB:    f.Color,
+	}
+}
+
+// Blend a pixel at a camera depth towards the fog color
+func (f *Fog) Apply(p utils.Pixel, depth float64) utils.Pixel {
+	if f.Mode == FogNone {
+		return p
+	}
+	amount := f.Amount(depth)
+	if amount <= 0 {
+		return p
+	}
+	return BlendPixel(p, f.Pixel(), amount)
+}
+
+// Luminance between 1-10 of a color, for ASCII output
+func lumaShade(rgb [3]uint8) uint8 {
+	luma := (.299*float64(rgb[0]) + .587*float64(rgb[1]) + .114*float64(rgb[2])) / 255
+	return uint8(math.Round(1 + 9*luma))
+}
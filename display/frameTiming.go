@@ -4,6 +4,12 @@ import "time"
 
 // Log the time the frame calculations began
 func (v *View) StartFrame() {
+	// The last frame's length is known now that it has ended
+	v.updateRecording()
+
+	// Resize between frames so the whole frame uses the same size
+	v.checkResize()
+
 	v.FrameStart = time.Now()
 
 }
@@ -12,6 +18,10 @@ func (v *View) StartFrame() {
 func (v *View) EndFrame() {
 
 	v.FrameTime = time.Since(v.FrameStart)
+
+	if v.Recorder != nil {
+		v.captureFrame()
+	}
 }
 
 // Minimize screen tearing by waiting until frametime for the target framerate
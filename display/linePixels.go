@@ -5,10 +5,9 @@ import (
 	"math"
 )
 
-// Adds contiguous line to framebuffer between 2 points w/ Bresenhams alg This
-// will always be calculated if faces are rendered, as these lines preface the
-// face area calculation
-func (v *View) DrawLine(start []uint16, end []uint16) [][]uint16 {
+// Calculates the pixels of a contiguous line between 2 points w/ Bresenhams
+// alg. Only used to draw the edges of the block wire frame
+func LinePixels(start []uint16, end []uint16) [][]uint16 {
 
 	startX := start[0]
 	startY := start[1]
@@ -81,13 +80,6 @@ func (v *View) DrawLine(start []uint16, end []uint16) [][]uint16 {
 		}
 
 	}
-	// Load all the pixels to framebuffer from whichever line alg was used
-	if utils.DrawWire {
-
-		for _, p := range pixels {
-			v.FrameBuffer[p[1]][p[0]] = utils.ColorMap["Cyan"][5]
-		}
-	}
 
 	return pixels
 
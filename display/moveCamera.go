@@ -8,6 +8,10 @@ import (
 
 // Camera rotation + transalation relative to normal
 func (v *View) HandleInput() {
+	if input.RecordPressed.Swap(false) {
+		v.ToggleRecording()
+	}
+
 	switch input.Key {
 	case "w":
 		v.MoveCam(math.Cos(utils.DegToRad(90-v.CamRot[1]))*v.CamMoveSpeed, 0, math.Sin(utils.DegToRad(90-v.CamRot[1]))*-v.CamMoveSpeed)
@@ -0,0 +1,55 @@
+package display
+
+import (
+	"go3d/utils"
+	"os"
+	"strings"
+)
+
+// How colors are encoded when the framebuffer is drawn to the terminal
+type OutputMode uint8
+
+const (
+	Output256       OutputMode = iota // xterm 256 color palette
+	OutputTrueColor                   // 24-bit RGB colors
+	OutputASCII                       // Plain characters by luminance, no escape codes
+)
+
+// Characters for each luminance in ASCII output, from empty to brightest.
+// With a character for each of the 10 shades, brighter pixels always get a
+// denser character
+var AsciiRamp = " .,:;-=+*%@"
+
+// Characters for the wire frame and verts in ASCII output, kept out of the
+// ramp so they stand out from faces
+const (
+	AsciiWire = '#'
+	AsciiVert = 'o'
+)
+
+// Character for a pixel luminance between 1-10. Filled pixels never use the
+// first, blank, entry of the ramp
+func ShadeChar(shade uint8) byte {
+	steps := len(AsciiRamp) - 1
+	i := 1 + (int(min(max(shade, 1), 10))-1)*(steps-1)/9
+	return AsciiRamp[min(i, steps)]
+}
+
+// Character for a filled pixel in ASCII output
+func asciiChar(p utils.Pixel) byte {
+	if p.Glyph != 0 {
+		return p.Glyph
+	}
+	return ShadeChar(p.Shade)
+}
+
+// Check if the terminal can't handle escape codes at all
+func IsDumbTerminal() bool {
+	return os.Getenv("TERM") == "dumb"
+}
+
+// Check if the terminal advertises 24-bit color support
+func SupportsTrueColor() bool {
+	colorTerm := strings.ToLower(os.Getenv("COLORTERM"))
+	return colorTerm == "truecolor" || colorTerm == "24bit"
+}
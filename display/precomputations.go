@@ -11,9 +11,11 @@ func (v *View) CalcMaxFrameTime() time.Duration {
 	return time.Duration(float64(time.Second) / float64(v.TargetFPS))
 }
 
+// Aspect ratio of the screen, accounting for the shape of pixels in the
+// current cell mode
 // TODO precompute this
 func (v *View) Aspect() float64 {
-	return float64(v.Xpx) / float64(v.Ypx)
+	return v.PixelAspect() * float64(v.Xpx) / float64(v.Ypx)
 }
 
 // Precompute projection matrix constants for camera aspects which do not change
@@ -1,212 +1,342 @@
 package display
 
 import (
+	"cmp"
+	"go3d/actors"
 	"go3d/utils"
 	"math"
+	"runtime"
 	"slices"
+	"sync"
 )
 
-// Apply world transformations -> camera transformations -> projection transformations -> NDC transformations -> screenspace transformations
+// A triangle after clipping and projection, with everything needed to draw it
+// to the framebuffer. Kept in the order the triangles were registered so that
+// tiles can be drawn independently with the same result
+type screenPolygon struct {
+	// Unrounded screenspace verts and their camera depths
+	screenVerts [][]float64
+	depthVals   []float64
+
+	// Shading values of each vert, interpolated per pixel, and the surface's
+	// color ready to be shaded. Pixels are mixed with what is behind them by
+	// the opacity
+	attrs   [][]float64
+	shader  utils.Shader
+	opacity float64
+
+	// Texture replacing the shader's color, multiplied by the tint
+	texture     *utils.Texture
+	textureTint [3]float64
+
+	// Pixels of the wire frame for block wires, or the polygon edges to draw
+	// for braille wires, by the index of their starting vert
+	wire      [][]uint16
+	wireEdges []int
+
+	// Verts which survived clipping, as screenspace x, y and depth
+	verts [][]float64
+
+	// Pixel bounding box of everything drawn by the polygon
+	minX, minY, maxX, maxY int
+}
+
+// Apply world transformations -> camera transformations -> projection transformations -> clipping -> NDC transformations -> screenspace transformations
 // Add results to the framebuffer (verts, lines, faces, lighting)
 // Most of the meat and potatoes for rendering
 func (v *View) PrepBuffer() {
 
-triangleLoop:
-	for _, a := range v.Triangles {
-		// Save parent for color assignment
-		parent := a.ObjRef
+	// Move every triangle into the world once, for both the shadow maps and
+	// the camera
+	world := v.WorldVerts()
 
-		//Save raster verts for connecting with lines & filling face after vertex pass
-		// Save lines drawn for filling in faces
-		var rasterVerts, rasterLines [][]uint16
+	// Find what each shadow casting light can see, before lighting faces
+	v.BuildShadowMaps(world)
 
-		// Store depth values for an approximated zbuffer
-		// Store worldspace verts for lighting calculations
-		var depthVals []float64
-		var worldVerts [][]float64
-		// Calculate vertecies
-		for _, vert := range a.Verts {
+	// Transform, light and clip all triangles
+	polygons := v.ProcessGeometry(world)
 
-			// Convert to worldspace
-			vert = utils.ApplyWorldMatrix(vert, parent.ObjX, parent.ObjY, parent.ObjZ, parent.Scale, parent.Rot)
+	// See through transparent faces to everything behind them
+	polygons = SortTransparent(polygons)
 
-			// Store world verts for lighting calculations
-			worldVerts = append(worldVerts, vert)
+	// Sort the polygons into screen tiles and draw each tile in parallel
+	tiles := v.BinPolygons(polygons)
+	v.RasterizeTiles(tiles, polygons)
 
-			vert = utils.ApplyCamMatrix(v.CamX, v.CamY, v.CamZ, v.CamRot, vert[0], vert[1], vert[2])
-			vert = utils.ApplyProjectionMatrix(vert, v.XProjConst, v.YProjConst, v.ZProjConst, v.WProjConst)
+	// Braille wires are depth tested against the finished faces
+	if v.Wire == WireBraille {
+		v.DrawBrailleWire(polygons)
+	}
 
-			// Prevent behind cam objects from drawing
-			if vert[3] > math.Abs(v.FarClip) || vert[3] < math.Abs(v.NearClip) {
-				continue triangleLoop
-			}
-			// Save depth vals for face rendering
-			depthVals = append(depthVals, vert[3])
+	// Draw debug stats on the screen in big text
+	if utils.Debug {
+		v.FrameCount++
+		v.DrawDebug()
+	}
 
-			vert = utils.ApplyNdcMatrix(vert)
-			// Discard if out of bounds
-			for _, v := range vert {
-				if v > 1 || v < -1 {
-					continue triangleLoop
-				}
+}
 
-			}
+// Number of goroutines used for rendering, defaulting to one per CPU
+func (v *View) WorkerCount() int {
+	if v.Workers > 0 {
+		return v.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
 
-			vert = utils.NdcToScreen(vert, v.Xpx, v.Ypx)
-			// fmt.Printf("Screenspace Vert: %v\n", ssVert)
+// Split the triangles into a chunk for each worker and run them in parallel
+func (v *View) forEachChunk(work func(w int, tris []*actors.Triangle, start int)) int {
+	workers := v.WorkerCount()
+	chunkSize := (len(v.Triangles) + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		start := w * chunkSize
+		end := min(start+chunkSize, len(v.Triangles))
+		if start >= end {
+			break
+		}
 
-			xVert := uint16(math.Round(vert[0]))
-			yVert := uint16(math.Round(vert[1]))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work(w, v.Triangles[start:end], start)
+		}()
+	}
+	wg.Wait()
 
-			// Save final 2D vertex for drawing lines
-			rasterVerts = append(rasterVerts, []uint16{xVert, yVert})
+	return workers
+}
 
+// Worldspace verts of every triangle, by the triangle's index in
+// View.Triangles
+func (v *View) WorldVerts() [][][]float64 {
+	world := make([][][]float64, len(v.Triangles))
+
+	// Work out each object's rotation once, it is used again to turn vertex
+	// normals when lighting
+	v.objRotations = map[*actors.Object]utils.Rotation{}
+	for _, tri := range v.Triangles {
+		if _, ok := v.objRotations[tri.ObjRef]; !ok {
+			v.objRotations[tri.ObjRef] = utils.NewRotation(tri.ObjRef.Rot)
 		}
-		// Load vertecies to buffer
-		if utils.DrawVerts {
-			go func() {
-				for _, vertex := range rasterVerts {
-
-					v.FrameBuffer[vertex[1]][vertex[0]] = utils.ColorMap["Red"][5]
-				}
-			}()
+	}
+
+	v.forEachChunk(func(w int, tris []*actors.Triangle, start int) {
+		// An object's triangles are next to each other, so its rotation is
+		// only looked up again when the object changes
+		var rotated *actors.Object
+		var rot utils.Rotation
+
+		for i, tri := range tris {
+			parent := tri.ObjRef
+			if parent != rotated {
+				rotated, rot = parent, v.objRotations[parent]
+			}
+
+			verts := make([][]float64, len(tri.Verts))
+			for j, vert := range tri.Verts {
+				verts[j] = utils.ApplyWorldRotation(vert, parent.ObjX, parent.ObjY, parent.ObjZ, parent.Scale, rot)
+			}
+			world[start+i] = verts
 		}
+	})
 
-		// Draw lines between 2D verts with bresenhams alg
-		if v.RenderWire {
+	return world
+}
 
-			// Keep track of connected points
-			connected := make(map[int]map[int]bool)
+// Run the geometry stage for every triangle, split across workers. The
+// resulting polygons keep the order of View.Triangles
+func (v *View) ProcessGeometry(world [][][]float64) []screenPolygon {
+	v.camRotation = utils.NewRotation(v.CamRot)
 
-			// Iterate through each vertex, connecting with neighbors and skipping if the reverse has been done
-			for i := range len(rasterVerts) {
-				for j := range len(rasterVerts) {
-					if i != j {
+	chunks := make([][]screenPolygon, v.WorkerCount())
+	culled := make([]int, v.WorkerCount())
 
-						// Check if i has been connected to anything
-						if _, ok := connected[i]; ok {
+	workers := v.forEachChunk(func(w int, tris []*actors.Triangle, start int) {
+		for i, tri := range tris {
+			poly, isCulled := v.ProjectTriangle(tri, world[start+i])
+			if isCulled {
+				culled[w]++
+			}
+			if poly != nil {
+				chunks[w] = append(chunks[w], *poly)
+			}
+		}
+	})
+
+	// Recombine in order
+	var polygons []screenPolygon
+	v.CulledTris = 0
+	for w := range workers {
+		polygons = append(polygons, chunks[w]...)
+		v.CulledTris += culled[w]
+	}
 
-							// Check if i has been connected to j, then skip
-							if _, ok := connected[i][j]; ok {
-								continue
-							}
-						}
+	return polygons
+}
 
-						drawn := v.DrawLine(rasterVerts[i], rasterVerts[j])
-						rasterLines = append(rasterLines, drawn...)
+// Move transparent polygons after the opaque ones, furthest first, so each is
+// mixed with the finished faces behind it. Opaque polygons keep their order
+func SortTransparent(polygons []screenPolygon) []screenPolygon {
+	// Most scenes have nothing see-through, leave their polygons as they are
+	if !slices.ContainsFunc(polygons, func(p screenPolygon) bool { return p.opacity < 1 }) {
+		return polygons
+	}
 
-						// Record the edge as drawn
-						if _, ok := connected[i]; !ok {
-							connected[i] = make(map[int]bool)
-						}
-						connected[i][j] = true
+	var opaque, transparent []screenPolygon
+	for _, p := range polygons {
+		if p.opacity < 1 {
+			transparent = append(transparent, p)
+		} else {
+			opaque = append(opaque, p)
+		}
+	}
 
-					}
+	slices.SortStableFunc(transparent, func(a screenPolygon, b screenPolygon) int {
+		return cmp.Compare(averageDepth(b), averageDepth(a))
+	})
+	return append(opaque, transparent...)
+}
 
-				}
+// Mean camera depth of a polygon's verts
+func averageDepth(p screenPolygon) float64 {
+	var depth float64
+	for _, d := range p.depthVals {
+		depth += d
+	}
+	return depth / float64(len(p.depthVals))
+}
 
-			}
+// Transform a triangle's worldspace verts to screenspace, returning nil if
+// nothing is visible. Also reports if the triangle was culled for facing the
+// wrong way
+func (v *View) ProjectTriangle(a *actors.Triangle, worldVerts [][]float64) (*screenPolygon, bool) {
+	// Save parent for color assignment
+	parent := a.ObjRef
 
-		}
+	// Store clip space verts for clipping against the view frustum
+	var clipVerts [][]float64
 
-		// Fill in faces via scanlines
-		if utils.RenderFace {
+	// Calculate vertecies
+	for _, vert := range worldVerts {
+		vert = utils.ApplyCamMatrix(v.CamX, v.CamY, v.CamZ, v.camRotation, vert[0], vert[1], vert[2])
+		vert = utils.ApplyProjectionMatrix(vert, v.XProjConst, v.YProjConst, v.ZProjConst, v.WProjConst)
 
-			// Calculate average depth for the face
-			var depth float64
-			for _, w := range depthVals {
-				depth += w
-			}
-			depth /= float64(len(depthVals))
-
-			// Calculate the min/max X and Y in triangle verts for bounding box
-			var maxX, maxY uint16
-			var minX, minY uint16 = math.MaxUint16, math.MaxUint16
-
-			for _, vert := range rasterVerts {
-
-				// Handle X
-				if vert[0] < minX {
-					minX = vert[0]
-				}
-				if vert[0] > maxX {
-					maxX = vert[0]
-				}
-				// Handle y
-				if vert[1] < minY {
-					minY = vert[1]
-				}
-				if vert[1] > maxY {
-					maxY = vert[1]
-				}
-			}
+		clipVerts = append(clipVerts, vert)
+	}
+
+	// Skip faces pointing the wrong way
+	if v.IsCulled(clipVerts, parent.Cull) {
+		return nil, true
+	}
 
-			// Reshape lines slice to be more useful here
-			var allPoints [][]uint16 = rasterLines
-			allPoints = append(allPoints, rasterVerts...)
+	// Skip lighting triangles which are entirely off screen
+	if v.OutsideFrustum(clipVerts) {
+		return nil, false
+	}
 
-			linePoints := make(map[uint16][]uint16)
+	// Light the triangle, the shading values are clipped along with the
+	// position of each vert
+	if utils.RenderFace {
+		attrs := v.ShadeVerts(a, worldVerts)
+		for i := range clipVerts {
+			clipVerts[i] = append(clipVerts[i], attrs[i]...)
+		}
+	}
 
-			// Map what x coordinates have been drawn with a given Y
-			for _, v := range allPoints {
-				x := v[0]
-				y := v[1]
+	// Cut away the parts of the triangle behind the camera, past the far
+	// clip or off screen. The result is a convex polygon of 3-9 verts
+	polygon := v.ClipPolygon(clipVerts)
+	if len(polygon) < 3 {
+		return nil, false
+	}
 
-				linePoints[y] = append(linePoints[y], x)
-			}
+	p := screenPolygon{
+		minX:    math.MaxInt,
+		minY:    math.MaxInt,
+		opacity: 1,
+	}
 
-			// Offsets for skipping pixels if wireframe is drawn
-			var lineOffsetLeft, lineOffsetRight uint16
-			lineOffsetRight = 1
-			if utils.DrawWire {
-				lineOffsetLeft = 1
-				lineOffsetRight = 0
-			}
+	//Save raster verts for connecting with lines
+	var rasterVerts [][]uint16
 
-			// Calculate barycenter of face for lighting
-			var xC, yC, zC float64
+	for _, vert := range polygon {
+		// Save depth vals and shading values for face rendering
+		p.depthVals = append(p.depthVals, vert[3])
+		p.attrs = append(p.attrs, vert[4:])
 
-			for _, point := range worldVerts {
-				xC += point[0]
-				yC += point[1]
-				zC += point[2]
-			}
-			xC /= float64(len(worldVerts))
-			yC /= float64(len(worldVerts))
-			zC /= float64(len(worldVerts))
-			center := []float64{xC, yC, zC}
+		vert = utils.NdcToScreen(utils.ApplyNdcMatrix(vert), v.Xpx, v.Ypx)
+		p.screenVerts = append(p.screenVerts, vert)
 
-			// Calculate face color based on lighting and camera depth
-			lum := v.CalculateFaceColor(depth, center, .3)
+		// Save final 2D vertex for drawing lines
+		raster := v.RasterPoint(vert)
+		rasterVerts = append(rasterVerts, raster)
 
-			// Within the bounding box, find the left and right raster bounds of triangle based on drawn lines
-			for y := minY; y < maxY; y++ {
-				if len(linePoints[y]) > 1 {
+		// Grow the bounding box, rounded verts will always contain the
+		// filled pixels, lines and verts
+		p.minX = min(p.minX, int(raster[0]))
+		p.minY = min(p.minY, int(raster[1]))
+		p.maxX = max(p.maxX, int(raster[0]))
+		p.maxY = max(p.maxY, int(raster[1]))
+	}
 
-					leftBound := slices.Min(linePoints[y])
-					rightBound := slices.Max(linePoints[y])
+	// Materials replace the object's color
+	if utils.RenderFace {
+		rgb := parent.RGB
+		if materialRGB := a.Material.RGB(); materialRGB != nil {
+			rgb = materialRGB
+		}
+		p.shader = utils.NewShader(parent.Color, rgb)
+		p.opacity = a.Material.Opacity()
 
-					// Draw in the pixels inbetween these
-					for x := leftBound + lineOffsetLeft; x < rightBound+lineOffsetRight; x++ {
-						// Only draw if the pixel is infront of other faces, based on average face depth
-						if v.DepthBuffer[y][x] > depth {
+		if p.texture = textureOf(a); p.texture != nil {
+			p.textureTint = [3]float64{1, 1, 1}
+			if d := a.Material.Diffuse; d != nil {
+				p.textureTint = [3]float64{min(max(d[0], 0), 1), min(max(d[1], 0), 1), min(max(d[2], 0), 1)}
+			}
+		}
+	}
 
-							v.FrameBuffer[y][x] = utils.ColorMap[parent.Color][lum]
-							v.DepthBuffer[y][x] = depth
-						}
-					}
-				}
+	// Lines around the visible polygon, skipping edges which were made by
+	// clipping as they are not part of the model
+	if v.RenderWire && utils.DrawWire {
+		for i := range polygon {
+			j := (i + 1) % len(polygon)
+			if v.OnClipPlane(polygon[i], polygon[j]) {
+				continue
+			}
 
+			// Braille lines are drawn at a higher resolution later on
+			if v.Wire == WireBraille {
+				p.wireEdges = append(p.wireEdges, i)
+				continue
 			}
 
+			// Bresenhams alg
+			p.wire = append(p.wire, LinePixels(rasterVerts[i], rasterVerts[j])...)
 		}
-
 	}
 
-	// Draw debug stats on the screen in big text
-	if utils.Debug {
-		v.FrameCount++
-		v.DrawDebug()
+	// Verts which survived clipping
+	if utils.DrawVerts {
+		for _, vert := range clipVerts {
+			if !v.InsideFrustum([][]float64{vert}) {
+				continue
+			}
+			screen := utils.NdcToScreen(utils.ApplyNdcMatrix(vert), v.Xpx, v.Ypx)
+			p.verts = append(p.verts, []float64{screen[0], screen[1], vert[3]})
+		}
 	}
 
+	return &p, false
+}
+
+// Round a screenspace point to a pixel, bounded to the screen to account for
+// floating point error at the clip edges
+func (v *View) RasterPoint(vert []float64) []uint16 {
+	x := min(max(math.Round(vert[0]), 0), float64(v.Xpx-1))
+	y := min(max(math.Round(vert[1]), 0), float64(v.Ypx-1))
+
+	return []uint16{uint16(x), uint16(y)}
 }
@@ -0,0 +1,151 @@
+package display
+
+import "math"
+
+// Verts are snapped to 1/256 of a pixel, so edge functions are whole numbers
+// and exact. Triangles sharing an edge always agree on which side a pixel is
+const subpixelBits = 8
+const subpixels = 1 << subpixelBits
+
+// A screenspace point snapped to the subpixel grid
+type fixedPoint [2]int64
+
+func toFixed(p []float64) fixedPoint {
+	return fixedPoint{int64(math.Round(p[0] * subpixels)), int64(math.Round(p[1] * subpixels))}
+}
+
+// Fill the part of a triangle of a polygon's verts within a tile using edge
+// functions over its bounding box. Pixels are sampled at their centers, and
+// pixels exactly on an edge are only drawn for top and left edges so that
+// shared edges are drawn once
+func (v *View) FillTriangle(p *screenPolygon, fan [3]int, t *tile) {
+	a, b, c := toFixed(p.screenVerts[fan[0]]), toFixed(p.screenVerts[fan[1]]), toFixed(p.screenVerts[fan[2]])
+	dA, dB, dC := p.depthVals[fan[0]], p.depthVals[fan[1]], p.depthVals[fan[2]]
+	attrA, attrB, attrC := p.attrs[fan[0]], p.attrs[fan[1]], p.attrs[fan[2]]
+
+	// Twice the signed area of the triangle, also the edge function of c
+	// against edge ab
+	area := FixedEdgeFunction(a, b, c[0], c[1])
+	if area == 0 {
+		return
+	}
+
+	// Wind every triangle the same way so inside is always positive
+	if area < 0 {
+		b, c = c, b
+		dB, dC = dC, dB
+		attrB, attrC = attrC, attrB
+		area = -area
+	}
+
+	// Bounding box of the triangle in whole pixels, bounded to the tile
+	minX := max(ceilPixel(min(a[0], b[0], c[0])), t.minX)
+	maxX := min(floorPixel(max(a[0], b[0], c[0])), t.maxX)
+	minY := max(ceilPixel(min(a[1], b[1], c[1])), t.minY)
+	maxY := min(floorPixel(max(a[1], b[1], c[1])), t.maxY)
+
+	// Edges opposite of each vert
+	topLeftA := IsTopLeft(b, c)
+	topLeftB := IsTopLeft(c, a)
+	topLeftC := IsTopLeft(a, b)
+
+	// Edge functions step by a constant amount for each pixel in X
+	stepA := -(c[1] - b[1]) * subpixels
+	stepB := -(a[1] - c[1]) * subpixels
+	stepC := -(b[1] - a[1]) * subpixels
+
+	// Interpolate 1/depth, as it is planar in screenspace
+	invA, invB, invC := 1/dA, 1/dB, 1/dC
+
+	// Shading values over depth are also planar, dividing by the
+	// interpolated 1/depth gives perspective correct values. Values which are
+	// the same at every vert are set once, keeping them exact
+	n := len(attrA)
+	buf := make([]float64, 4*n)
+	overA, overB, overC, attrs := buf[:n], buf[n:2*n], buf[2*n:3*n], buf[3*n:]
+	varying := make([]int, 0, n)
+	for k := range n {
+		if attrA[k] == attrB[k] && attrA[k] == attrC[k] {
+			attrs[k] = attrA[k]
+			continue
+		}
+		varying = append(varying, k)
+		overA[k] = attrA[k] * invA
+		overB[k] = attrB[k] * invB
+		overC[k] = attrC[k] * invC
+	}
+
+	fArea := float64(area)
+	for y := minY; y <= maxY; y++ {
+		fx, fy := int64(minX)<<subpixelBits, int64(y)<<subpixelBits
+		eA := FixedEdgeFunction(b, c, fx, fy)
+		eB := FixedEdgeFunction(c, a, fx, fy)
+		eC := FixedEdgeFunction(a, b, fx, fy)
+
+		for x := minX; x <= maxX; x, eA, eB, eC = x+1, eA+stepA, eB+stepB, eC+stepC {
+
+			// Skip pixels outside of the triangle, or on an edge owned by a
+			// neighboring triangle
+			if !InsideEdge(eA, topLeftA) || !InsideEdge(eB, topLeftB) || !InsideEdge(eC, topLeftC) {
+				continue
+			}
+
+			// Only draw if the pixel is infront of other faces, based on the
+			// depth of the face at this pixel
+			wA, wB, wC := float64(eA), float64(eB), float64(eC)
+			inv := wA*invA + wB*invB + wC*invC
+			pxDepth := fArea / inv
+			if v.DepthBuffer[y][x] > pxDepth {
+				for _, k := range varying {
+					attrs[k] = (wA*overA[k] + wB*overB[k] + wC*overC[k]) / inv
+				}
+				px := v.ShadeFragment(p, attrs, pxDepth)
+
+				// Transparent faces are drawn after everything behind them,
+				// and don't hide what is drawn after them
+				if p.opacity < 1 {
+					v.FrameBuffer[y][x] = BlendPixel(v.FrameBuffer[y][x], px, p.opacity)
+					continue
+				}
+				v.FrameBuffer[y][x] = px
+				v.DepthBuffer[y][x] = pxDepth
+			}
+		}
+	}
+}
+
+// Signed area of the parallelogram between edge ab and point (x, y). Positive
+// when the point is on the inner side of the edge
+func EdgeFunction(a []float64, b []float64, x float64, y float64) float64 {
+	return (b[0]-a[0])*(y-a[1]) - (b[1]-a[1])*(x-a[0])
+}
+
+// Edge function of points on the subpixel grid, exact for any coordinates on
+// screen
+func FixedEdgeFunction(a fixedPoint, b fixedPoint, x int64, y int64) int64 {
+	return (b[0]-a[0])*(y-a[1]) - (b[1]-a[1])*(x-a[0])
+}
+
+// Nearest whole pixel at or above a subpixel coordinate
+func ceilPixel(f int64) int {
+	return int(-((-f) >> subpixelBits))
+}
+
+// Nearest whole pixel at or below a subpixel coordinate
+func floorPixel(f int64) int {
+	return int(f >> subpixelBits)
+}
+
+// Check if the edge ab is a top or left edge of a consistently wound triangle
+func IsTopLeft(a fixedPoint, b fixedPoint) bool {
+	dx := b[0] - a[0]
+	dy := b[1] - a[1]
+
+	// Top edges are flat with the triangle below, left edges go up the screen
+	return (dy == 0 && dx > 0) || dy < 0
+}
+
+// Apply the fill rule to a pixel's edge function value
+func InsideEdge(e int64, topLeft bool) bool {
+	return e > 0 || (e == 0 && topLeft)
+}
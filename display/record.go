@@ -0,0 +1,223 @@
+package display
+
+import (
+	"bytes"
+	"go3d/utils"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// Longest recording started from the keyboard, recordings stop and save once
+// they reach their max duration
+const DefaultRecordLimit = 30 * time.Second
+
+// Pixel scale of recordings started from the keyboard
+const DefaultRecordScale = 2
+
+// xterm 256 colors, in palette order so pixel colors are palette indexes
+var xtermPalette = func() color.Palette {
+	p := make(color.Palette, 256)
+	for i := range p {
+		rgb := utils.XtermRGB(uint8(i))
+		p[i] = color.RGBA{rgb[0], rgb[1], rgb[2], 255}
+	}
+	return p
+}()
+
+// Palette index used for empty pixels, black outside of the system colors
+const gifBackground = 16
+
+// Captures rendered frames into an animated GIF. Frames are kept in memory
+// until the recording is saved
+type Recorder struct {
+	Path        string
+	MaxDuration time.Duration
+	Scale       int
+
+	frames []*image.Paletted
+	delays []int
+
+	// Total length of the frames with a delay, and the part of it already
+	// written as delays. GIF delays are in 100ths of a second, so rounding is
+	// carried over to the next frame to prevent drift, as is the time of
+	// frames too short to be shown
+	elapsed time.Duration
+	written int
+
+	// The last frame is waiting for its delay
+	pending bool
+}
+
+// Start recording frames to a GIF, saved once StopRecording is called or the
+// max duration is reached. A max duration of 0 uses DefaultRecordLimit
+func (v *View) StartRecording(path string, maxDuration time.Duration) {
+	if maxDuration <= 0 {
+		maxDuration = DefaultRecordLimit
+	}
+	v.Recorder = &Recorder{
+		Path:        path,
+		MaxDuration: maxDuration,
+		Scale:       DefaultRecordScale,
+	}
+	v.RecordErr = nil
+}
+
+// Stop the current recording and save it, the last frame lasts as long as
+// the frame time
+func (v *View) StopRecording() error {
+	r := v.Recorder
+	if r == nil {
+		return nil
+	}
+	v.Recorder = nil
+
+	r.endFrame(v.frameDuration())
+	r.holdLastFrame()
+	v.RecordErr = r.Save()
+	return v.RecordErr
+}
+
+// Start recording to a new GIF in the working directory, or stop and save
+// the current recording, at the start of the next frame. Can be called from
+// any goroutine
+func (v *View) ToggleRecording() {
+	v.recordToggled.Store(true)
+}
+
+// Start or stop recording when toggled, and stop once the max duration is
+// reached. Called at the start of each frame
+func (v *View) updateRecording() {
+	if v.recordToggled.Swap(false) {
+		if v.Recorder == nil {
+			v.StartRecording("goren-"+time.Now().Format("20060102-150405")+".gif", DefaultRecordLimit)
+		} else {
+			v.StopRecording()
+		}
+		return
+	}
+	if v.Recorder == nil {
+		return
+	}
+
+	// The previous frame has ended, so its length is known
+	v.Recorder.endFrame(v.frameDuration())
+	if v.Recorder.elapsed >= v.Recorder.MaxDuration {
+		v.StopRecording()
+	}
+}
+
+// Length of the last frame. Includes the frame sync wait when FrameSync was
+// used, otherwise only the frame time
+func (v *View) frameDuration() time.Duration {
+	return max(v.FrameEnd, v.FrameTime)
+}
+
+// Capture the framebuffer as the next frame of the recording
+func (v *View) captureFrame() {
+	r := v.Recorder
+	scale := max(r.Scale, 1)
+	img := image.NewPaletted(image.Rect(0, 0, int(v.Xpx)*scale, int(v.Ypx)*scale), xtermPalette)
+
+	for y, row := range v.FrameBuffer {
+		for x, p := range row {
+			index := uint8(gifBackground)
+			if p.Filled {
+				index = p.Color
+			}
+			for dy := range scale {
+				start := img.PixOffset(x*scale, y*scale+dy)
+				for dx := range scale {
+					img.Pix[start+dx] = index
+				}
+			}
+		}
+	}
+
+	if v.Wire == WireBraille {
+		v.brailleDotRects(scale, func(rect image.Rectangle, p utils.Pixel) {
+			for y := rect.Min.Y; y < rect.Max.Y; y++ {
+				for x := rect.Min.X; x < rect.Max.X; x++ {
+					img.SetColorIndex(x, y, p.Color)
+				}
+			}
+		})
+	}
+
+	r.addFrame(img)
+}
+
+// Add a frame, or extend the last one if nothing changed. A last frame which
+// was too short to get a delay is replaced
+func (r *Recorder) addFrame(img *image.Paletted) {
+	n := len(r.frames)
+	if n > 0 && bytes.Equal(r.frames[n-1].Pix, img.Pix) {
+		r.pending = true
+		return
+	}
+	r.pending = true
+	if n > 0 && r.delays[n-1] == 0 {
+		r.frames[n-1] = img
+		return
+	}
+	r.frames = append(r.frames, img)
+	r.delays = append(r.delays, 0)
+}
+
+// Give the last frame its delay once its length is known
+func (r *Recorder) endFrame(length time.Duration) {
+	if !r.pending {
+		return
+	}
+	r.pending = false
+	r.elapsed += length
+
+	// Players slow down delays under 2, so frames shorter than that are left
+	// out, and their time is added to the next frame instead
+	total := int((r.elapsed + 5*time.Millisecond) / (10 * time.Millisecond))
+	delay := total - r.written
+	if r.delays[len(r.delays)-1]+delay < 2 {
+		return
+	}
+	r.delays[len(r.delays)-1] += delay
+	r.written += delay
+}
+
+// Hold the last frame for at least the shortest delay players keep, as no
+// frame comes after it to take its time
+func (r *Recorder) holdLastFrame() {
+	if n := len(r.delays); n > 0 {
+		r.delays[n-1] = max(r.delays[n-1], 2)
+	}
+}
+
+// Number of frames captured so far
+func (r *Recorder) Frames() int {
+	return len(r.frames)
+}
+
+// Encode the recording as an animated GIF
+func (r *Recorder) WriteGIF(w io.Writer) error {
+	// Frames change size if the view was resized while recording, so the
+	// GIF has to fit the largest of them
+	var config image.Config
+	for _, f := range r.frames {
+		config.Width = max(config.Width, f.Rect.Dx())
+		config.Height = max(config.Height, f.Rect.Dy())
+	}
+	config.ColorModel = xtermPalette
+
+	return gif.EncodeAll(w, &gif.GIF{
+		Image:           r.frames,
+		Delay:           r.delays,
+		Config:          config,
+		BackgroundIndex: gifBackground,
+	})
+}
+
+// Save the recording to its path
+func (r *Recorder) Save() error {
+	return saveFile(r.Path, r.WriteGIF)
+}
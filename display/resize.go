@@ -0,0 +1,79 @@
+package display
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// Read the size of the terminal on stdin in character cells
+func terminalSize() (uint16, uint16, bool) {
+	var ws struct {
+		Rows uint16
+		Cols uint16
+		X    uint16
+		Y    uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(0), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	return ws.Cols, ws.Rows, errno == 0
+}
+
+// Listen for the terminal being resized. The new size is applied at the start
+// of the next frame, so a frame is never drawn with half updated buffers
+func (v *View) WatchResize() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGWINCH)
+
+	go func() {
+		for range sigs {
+			v.resized.Store(true)
+		}
+	}()
+}
+
+// Apply a pending terminal resize, called between frames
+func (v *View) checkResize() {
+	if !v.resized.Swap(false) {
+		return
+	}
+
+	cols, rows, ok := terminalSize()
+	if !ok || rows < 2 {
+		return
+	}
+
+	// Leave the last row free so the frame never scrolls the terminal
+	rows--
+	if cols == v.TermCols && rows == v.TermRows {
+		return
+	}
+
+	v.Resize(cols, rows)
+
+	// Wipe what the terminal reflowed from the old layout
+	if v.Output != OutputASCII {
+		fmt.Fprint(v.Out, "\033[2J")
+	}
+}
+
+// Resize the view to a size in pixels, the same unit as CreateHeadlessView.
+// Call this between frames, as with Resize
+func (v *View) ResizePixels(width uint16, height uint16) {
+	v.Resize(v.cellsFor(width, height))
+}
+
+// Resize the view to fill a new terminal area, keeping the scene, camera and
+// settings. Buffers and projection are rebuilt, so call this between frames
+func (v *View) Resize(cols uint16, rows uint16) {
+	v.ApplySize(cols, rows)
+
+	// Move the debug overlay to the corner if it would start off screen
+	if v.OverlayOrigin[0] >= v.Xpx {
+		v.OverlayOrigin[0] = 0
+	}
+	if v.OverlayOrigin[1] >= v.Ypx {
+		v.OverlayOrigin[1] = 0
+	}
+}
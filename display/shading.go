@@ -0,0 +1,173 @@
+package display
+
+import (
+	"go3d/actors"
+	"go3d/utils"
+	"slices"
+)
+
+// Shading values carried by each vert after its clip space position. They are
+// clipped and interpolated across the face with the position
+const (
+	// Light reaching the surface in each color channel, other than from
+	// lights with shadow maps
+	attrLightR = iota
+	attrLightG
+	attrLightB
+
+	// Texture coordinates, 0 for triangles without a texture
+	attrU
+	attrV
+
+	// Worldspace position, only carried when there are shadow maps to
+	// test against
+	attrWorldX
+	attrWorldY
+	attrWorldZ
+
+	// Light from each shadow map's light, by the index of the map, before
+	// testing if it is blocked
+	attrShadow
+)
+
+// Light a triangle, returning the shading values of each vert. Smooth shading
+// lights every vert on its own, while flat shading lights the face once at
+// its center and gives every vert the same values
+func (v *View) ShadeVerts(tri *actors.Triangle, worldVerts [][]float64) [][]float64 {
+	parent := tri.ObjRef
+	attrs := make([][]float64, len(worldVerts))
+
+	// Calculate barycenter of face for lighting
+	var xC, yC, zC float64
+	for _, point := range worldVerts {
+		xC += point[0]
+		yC += point[1]
+		zC += point[2]
+	}
+	xC /= float64(len(worldVerts))
+	yC /= float64(len(worldVerts))
+	zC /= float64(len(worldVerts))
+	center := []float64{xC, yC, zC}
+
+	// .obj faces are counter clockwise, so the normal points out of the face
+	normal := utils.FaceNormal(worldVerts[0], worldVerts[1], worldVerts[2])
+
+	// Faces seen from behind are lit on the side facing the camera, for
+	// objects drawn without back face culling
+	toCam := []float64{v.CamX - xC, v.CamY - yC, v.CamZ - zC}
+	flip := utils.Dot(normal, toCam) < 0
+	if flip {
+		normal = []float64{-normal[0], -normal[1], -normal[2]}
+	}
+
+	if parent.Shading == actors.ShadeSmooth {
+		rot := v.objRotations[parent]
+		for i, vert := range worldVerts {
+			// Vertex normals turn with the object, the face normal is used
+			// for triangles without them
+			n := normal
+			if tri.Normals != nil {
+				n = rot.Apply(tri.Normals[i])
+				if flip {
+					n = []float64{-n[0], -n[1], -n[2]}
+				}
+			}
+			attrs[i] = v.lightAttrs(vert, n, tri)
+		}
+	} else {
+		// Calculate face color based on lighting. Shadows, textures and fog
+		// are still applied to each pixel, at its own position
+		face := v.lightAttrs(center, normal, tri)
+		for i, vert := range worldVerts {
+			attrs[i] = face
+			if len(v.shadowMaps) > 0 || textureOf(tri) != nil {
+				attrs[i] = slices.Clone(face)
+			}
+			if len(v.shadowMaps) > 0 {
+				copy(attrs[i][attrWorldX:], shadowPoint(v.shadowMaps, vert, normal))
+			}
+		}
+	}
+
+	// Texture coordinates are interpolated with the lighting
+	if textureOf(tri) != nil {
+		for i, uv := range tri.UVs {
+			attrs[i][attrU] = uv[0]
+			attrs[i][attrV] = uv[1]
+		}
+	}
+	return attrs
+}
+
+// Texture drawn over a triangle, nil if it has no texture or no UVs to map
+// it with
+func textureOf(tri *actors.Triangle) *utils.Texture {
+	if tri.Material == nil || tri.UVs == nil {
+		return nil
+	}
+	return tri.Material.Texture
+}
+
+// Light a point of a triangle and pack it into shading values
+func (v *View) lightAttrs(point []float64, normal []float64, tri *actors.Triangle) []float64 {
+	n := attrWorldX
+	if len(v.shadowMaps) > 0 {
+		n = attrShadow + len(v.shadowMaps)
+	}
+	attrs := make([]float64, n)
+
+	if !utils.RenderLighting {
+		return attrs
+	}
+
+	light, shadowed := v.GatherLight(point, normal, tri)
+	copy(attrs[attrLightR:], light[:])
+
+	if len(v.shadowMaps) > 0 {
+		copy(attrs[attrWorldX:], shadowPoint(v.shadowMaps, point, normal))
+		copy(attrs[attrShadow:], shadowed)
+	}
+	return attrs
+}
+
+// Point tested against the shadow maps for a surface. It is moved off the
+// surface by a few texels, so faces at a steep angle to a light don't
+// shadow themselves
+func shadowPoint(maps []*shadowMap, point []float64, normal []float64) []float64 {
+	var offset float64
+	for _, m := range maps {
+		offset = max(offset, 3*m.TexelSize(point))
+	}
+	return []float64{
+		point[0] + normal[0]*offset,
+		point[1] + normal[1]*offset,
+		point[2] + normal[2]*offset,
+	}
+}
+
+// Color of a single pixel of a polygon from its interpolated shading values
+// and camera depth. Light from shadow casting lights is only added if nothing
+// blocks it, and the result is faded into the fog
+func (v *View) ShadeFragment(p *screenPolygon, attrs []float64, depth float64) utils.Pixel {
+	light := [3]float64{attrs[attrLightR], attrs[attrLightG], attrs[attrLightB]}
+
+	for i, m := range v.shadowMaps {
+		amount := attrs[attrShadow+i]
+		if amount > 0 {
+			addLight(&light, amount*m.Visibility(attrs[attrWorldX:attrWorldZ+1]), m.color)
+		}
+	}
+
+	// Textured polygons are shaded from the texel under the pixel
+	shader := &p.shader
+	if p.texture != nil {
+		texel := p.texture.Sample(attrs[attrU], attrs[attrV], v.TextureFilter)
+		for i, c := range texel {
+			texel[i] = uint8(float64(c)*p.textureTint[i] + .5)
+		}
+		textured := utils.RGBShader(texel)
+		shader = &textured
+	}
+
+	return v.Fog.Apply(shader.Tinted(LightShade(light)), depth)
+}
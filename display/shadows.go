@@ -0,0 +1,546 @@
+package display
+
+import (
+	"go3d/actors"
+	"go3d/utils"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Closest distance from a point or spot light that is drawn to its shadow map
+const shadowNear = .05
+
+// Depth of the closest shadow casters as seen from a light. Point lights look
+// out of the 6 faces of a cube, spot lights down their cone and directional
+// lights over the whole scene
+type shadowMap struct {
+	light actors.LightSource
+	color *[3]float64
+
+	// Position of point and spot lights, nil for directional lights. Nothing
+	// past far is drawn, as the light doesn't reach it
+	pos   []float64
+	far   float64
+	faces []*shadowFace
+
+	// Worldspace verts of every triangle the map was drawn from, and the
+	// indexes of the casters within reach of the light
+	world   [][][]float64
+	casters []int
+}
+
+// A single depth image of a shadow map
+type shadowFace struct {
+	// Light space axes, the face looks along forward
+	right, up, forward []float64
+
+	// Perspective faces are scaled by the view angle, orthographic faces
+	// cover the area between the min and max of each axis
+	ortho      bool
+	scale      float64
+	minU, minV float64
+	spanU      float64
+	spanV      float64
+	minF       float64
+
+	size  int
+	depth []float64
+}
+
+// Render a shadow map for every registered light with shadows enabled, from
+// the worldspace verts of every triangle. Faces of the maps which look the
+// same way as last frame, with no casters moving in their view, are kept
+// instead of drawn again
+func (v *View) BuildShadowMaps(world [][][]float64) {
+	prev := v.shadowMaps
+	v.shadowMaps = nil
+	if !utils.RenderFace || !utils.RenderLighting || !slices.ContainsFunc(v.Lights, castsShadows) {
+		return
+	}
+
+	size := max(v.ShadowMapSize, 1)
+	casters := v.shadowCasters(world)
+	casterVerts := make([][][]float64, len(casters))
+	for i, c := range casters {
+		casterVerts[i] = world[c]
+	}
+
+	for _, light := range v.Lights {
+		if !castsShadows(light) {
+			continue
+		}
+
+		view := light.(actors.ShadowCaster).ShadowView()
+		switch view.Projection {
+		case actors.ShadowCube:
+			v.shadowMaps = append(v.shadowMaps, cubeShadowMap(light, view, size))
+		case actors.ShadowCone:
+			v.shadowMaps = append(v.shadowMaps, spotShadowMap(light, view, size))
+		case actors.ShadowParallel:
+			if m := directionalShadowMap(light, view, casterVerts, size); m != nil {
+				v.shadowMaps = append(v.shadowMaps, m)
+			}
+		}
+	}
+
+	// Draw every changed face of every map in parallel
+	var faces []*shadowFace
+	var maps []*shadowMap
+	for _, m := range v.shadowMaps {
+		m.world = world
+		m.casters = m.reachingCasters(world, casters)
+
+		// Faces are kept from the last frame when the light hasn't moved,
+		// and none of the casters which moved are in view before or after
+		last := findShadowMap(prev, m.light)
+		keep := last != nil && len(last.faces) == len(m.faces) && last.far == m.far && slices.Equal(last.pos, m.pos)
+		var moved [][][]float64
+		if keep {
+			moved, keep = m.movedCasters(last)
+		}
+
+		for i, f := range m.faces {
+			// Changed faces are drawn over the last frame's depth image
+			if last != nil && i < len(last.faces) {
+				f.depth = last.faces[i].depth
+			}
+			if keep && f.sameView(last.faces[i]) && !f.seesAny(m.pos, m.far, moved) {
+				continue
+			}
+			faces = append(faces, f)
+			maps = append(maps, m)
+		}
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(v.WorkerCount(), len(faces)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(faces) {
+					return
+				}
+				faces[i].render(maps[i].pos, maps[i].far, maps[i].world, maps[i].casters)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Check if a light has shadows enabled, for the kinds of light which can
+// cast them
+func castsShadows(light actors.LightSource) bool {
+	c, ok := light.(actors.ShadowCaster)
+	return ok && c.CastsShadows()
+}
+
+// Find the map of a light among shadow maps, nil if it has none
+func findShadowMap(maps []*shadowMap, light actors.LightSource) *shadowMap {
+	for _, m := range maps {
+		if m.light == light {
+			return m
+		}
+	}
+	return nil
+}
+
+// Indexes of the casters the map's light can reach, all of them for
+// directional lights. Casters past the end of a light can't block it from
+// anything it lights
+func (m *shadowMap) reachingCasters(world [][][]float64, casters []int) []int {
+	if m.pos == nil {
+		return casters
+	}
+
+	var reached []int
+	for _, c := range casters {
+		tri := world[c]
+
+		// Distance to the closest point of the triangle's bounding box
+		var dist2 float64
+		for axis := range 3 {
+			lo := min(tri[0][axis], tri[1][axis], tri[2][axis])
+			hi := max(tri[0][axis], tri[1][axis], tri[2][axis])
+			d := min(max(m.pos[axis], lo), hi) - m.pos[axis]
+			dist2 += d * d
+		}
+		if dist2 <= m.far*m.far {
+			reached = append(reached, c)
+		}
+	}
+	return reached
+}
+
+// Verts of the casters which moved, came within reach or went out of reach
+// since the last frame's map, in both their old and new places. Returns false
+// if the triangles can't be matched up, such as after objects were added
+func (m *shadowMap) movedCasters(last *shadowMap) ([][][]float64, bool) {
+	if len(m.world) != len(last.world) {
+		return nil, false
+	}
+
+	// Both lists of casters are in triangle order
+	var moved [][][]float64
+	a, b := m.casters, last.casters
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j >= len(b) || (i < len(a) && a[i] < b[j]):
+			moved = append(moved, m.world[a[i]])
+			i++
+		case i >= len(a) || b[j] < a[i]:
+			moved = append(moved, last.world[b[j]])
+			j++
+		default:
+			if !sameTriangle(m.world[a[i]], last.world[b[j]]) {
+				moved = append(moved, m.world[a[i]], last.world[b[j]])
+			}
+			i++
+			j++
+		}
+	}
+	return moved, true
+}
+
+func sameTriangle(a [][]float64, b [][]float64) bool {
+	for i, p := range a {
+		if !slices.Equal(p, b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check if two faces look the same way over the same area
+func (f *shadowFace) sameView(g *shadowFace) bool {
+	return slices.Equal(f.right, g.right) && slices.Equal(f.up, g.up) && slices.Equal(f.forward, g.forward) &&
+		f.ortho == g.ortho && f.scale == g.scale && f.size == g.size &&
+		f.minU == g.minU && f.minV == g.minV && f.minF == g.minF &&
+		f.spanU == g.spanU && f.spanV == g.spanV
+}
+
+// Find the shadow map of a light, nil if it has none
+func (v *View) shadowMapOf(light actors.LightSource) (int, *shadowMap) {
+	for i, m := range v.shadowMaps {
+		if m.light == light {
+			return i, m
+		}
+	}
+	return -1, nil
+}
+
+// Indexes of every triangle which casts shadows
+func (v *View) shadowCasters(world [][][]float64) []int {
+	var casters []int
+	for i, tri := range v.Triangles {
+		if tri.ObjRef.CastsShadows {
+			casters = append(casters, i)
+		}
+	}
+	return casters
+}
+
+// Shadow map looking out of each side of a cube around a point
+func cubeShadowMap(light actors.LightSource, view actors.ShadowView, size int) *shadowMap {
+	m := &shadowMap{light: light, color: view.Color, pos: view.Pos, far: view.Far}
+
+	axes := [][3][]float64{
+		{{0, 0, -1}, {0, 1, 0}, {1, 0, 0}},
+		{{0, 0, 1}, {0, 1, 0}, {-1, 0, 0}},
+		{{1, 0, 0}, {0, 0, -1}, {0, 1, 0}},
+		{{1, 0, 0}, {0, 0, 1}, {0, -1, 0}},
+		{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		{{-1, 0, 0}, {0, 1, 0}, {0, 0, -1}},
+	}
+	for _, a := range axes {
+		m.faces = append(m.faces, newShadowFace(a[0], a[1], a[2], 1, size))
+	}
+	return m
+}
+
+// Shadow map looking down a spot light's cone
+func spotShadowMap(light actors.LightSource, view actors.ShadowView, size int) *shadowMap {
+	m := &shadowMap{light: light, color: view.Color, pos: view.Pos, far: view.Far}
+
+	forward := utils.Normalize(view.Dir)
+	right, up := perpendicularAxes(forward)
+
+	// Wide enough for the whole cone, within what a single face can show
+	halfAngle := min(view.Angle, 80)
+	scale := 1 / math.Tan(utils.DegToRad(halfAngle))
+
+	m.faces = append(m.faces, newShadowFace(right, up, forward, scale, size))
+	return m
+}
+
+// Shadow map looking along a directional light, covering every shadow caster
+func directionalShadowMap(light actors.LightSource, view actors.ShadowView, casters [][][]float64, size int) *shadowMap {
+	forward := utils.Normalize(view.Dir)
+	if utils.Length(forward) == 0 || len(casters) == 0 {
+		return nil
+	}
+	right, up := perpendicularAxes(forward)
+
+	// Bound the casters along each axis, nothing outside can be in shadow
+	minU, minV, minF := math.Inf(1), math.Inf(1), math.Inf(1)
+	maxU, maxV := math.Inf(-1), math.Inf(-1)
+	for _, tri := range casters {
+		for _, p := range tri {
+			u, v, d := utils.Dot(p, right), utils.Dot(p, up), utils.Dot(p, forward)
+			minU, maxU = min(minU, u), max(maxU, u)
+			minV, maxV = min(minV, v), max(maxV, v)
+			minF = min(minF, d)
+		}
+	}
+
+	f := newShadowFace(right, up, forward, 1, size)
+	f.ortho = true
+	f.minU, f.minV, f.minF = minU, minV, minF
+	f.spanU = max(maxU-minU, 1e-6)
+	f.spanV = max(maxV-minV, 1e-6)
+
+	return &shadowMap{light: light, color: view.Color, far: math.Inf(1), faces: []*shadowFace{f}}
+}
+
+func newShadowFace(right []float64, up []float64, forward []float64, scale float64, size int) *shadowFace {
+	return &shadowFace{
+		right:   right,
+		up:      up,
+		forward: forward,
+		scale:   scale,
+		size:    size,
+	}
+}
+
+// Two unit axes perpendicular to a unit direction and each other
+func perpendicularAxes(forward []float64) ([]float64, []float64) {
+	ref := []float64{0, 1, 0}
+	if math.Abs(forward[1]) > .9 {
+		ref = []float64{1, 0, 0}
+	}
+	right := utils.Normalize(utils.Cross(forward, ref))
+	up := utils.Cross(right, forward)
+	return right, up
+}
+
+// Convert a worldspace point to clip space (x, y, z, w) for the face, where
+// w is the depth along the face, followed by the depth to store
+func (f *shadowFace) clipTo(c []float64, p []float64, pos []float64) {
+	if f.ortho {
+		// Map the bounds to -1 to 1 like a perspective face
+		c[0] = 2*(utils.Dot(p, f.right)-f.minU)/f.spanU - 1
+		c[1] = 2*(utils.Dot(p, f.up)-f.minV)/f.spanV - 1
+		c[2], c[3] = 0, 1
+		c[4] = utils.Dot(p, f.forward) - f.minF
+		return
+	}
+
+	dx, dy, dz := p[0]-pos[0], p[1]-pos[1], p[2]-pos[2]
+	w := dx*f.forward[0] + dy*f.forward[1] + dz*f.forward[2]
+	c[0] = (dx*f.right[0] + dy*f.right[1] + dz*f.right[2]) * f.scale
+	c[1] = (dx*f.up[0] + dy*f.up[1] + dz*f.up[2]) * f.scale
+	c[2], c[3], c[4] = 0, w, w
+}
+
+// Convert a clip space vert to the face's texels, as x, y and depth
+func (f *shadowFace) texel(c []float64) (float64, float64, float64) {
+	s := float64(f.size)
+	x := (c[0]/c[3] + 1) / 2 * s
+	y := (1 - (c[1]/c[3]+1)/2) * s
+	return x, y, c[4]
+}
+
+// Draw the depth of every caster in view of the face
+func (f *shadowFace) render(pos []float64, far float64, world [][][]float64, casters []int) {
+	if len(f.depth) != f.size*f.size {
+		f.depth = make([]float64, f.size*f.size)
+	}
+	for i := range f.depth {
+		f.depth[i] = math.Inf(1)
+	}
+
+	var buf [3][5]float64
+	verts := [][]float64{buf[0][:], buf[1][:], buf[2][:]}
+
+	for _, c := range casters {
+		for i, p := range world[c] {
+			f.clipTo(verts[i], p, pos)
+		}
+
+		// Reuse the camera's clipping, with the near plane close to the light
+		// and the far plane where the light ends. Most casters are entirely
+		// in or out of view and don't need clipping
+		side := f.clipSide(verts, far)
+		if side < 0 {
+			continue
+		}
+		polygon := verts
+		if side == 0 {
+			for plane := range clipPlaneCount {
+				if len(polygon) < 3 {
+					break
+				}
+				polygon = clipAgainstPlane(polygon, plane, shadowNear, far)
+			}
+		}
+
+		for j := 1; j < len(polygon)-1; j++ {
+			f.fillDepth(polygon[0], polygon[j], polygon[j+1])
+		}
+	}
+}
+
+// Check if any of the triangles are in view of the face
+func (f *shadowFace) seesAny(pos []float64, far float64, tris [][][]float64) bool {
+	var buf [3][5]float64
+	verts := [][]float64{buf[0][:], buf[1][:], buf[2][:]}
+
+	for _, tri := range tris {
+		for i, p := range tri {
+			f.clipTo(verts[i], p, pos)
+		}
+		if f.clipSide(verts, far) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Where a clip space triangle is against the face's view, -1 if entirely
+// outside one of the planes, 1 if inside all of them and 0 if it needs
+// clipping
+func (f *shadowFace) clipSide(verts [][]float64, far float64) int {
+	inside := true
+	for plane := range clipPlaneCount {
+		dA := planeDistance(verts[0], plane, shadowNear, far)
+		dB := planeDistance(verts[1], plane, shadowNear, far)
+		dC := planeDistance(verts[2], plane, shadowNear, far)
+		if dA < 0 && dB < 0 && dC < 0 {
+			return -1
+		}
+		inside = inside && dA >= 0 && dB >= 0 && dC >= 0
+	}
+	if inside {
+		return 1
+	}
+	return 0
+}
+
+// Keep the closest depth of a clip space triangle at each texel it covers.
+// Depth is linear for orthographic faces, and found from 1/w in perspective
+func (f *shadowFace) fillDepth(ca []float64, cb []float64, cc []float64) {
+	ax, ay, dA := f.texel(ca)
+	bx, by, dB := f.texel(cb)
+	cx, cy, dC := f.texel(cc)
+	a, b, c := []float64{ax, ay}, []float64{bx, by}, []float64{cx, cy}
+
+	area := EdgeFunction(a, b, cx, cy)
+	if area == 0 {
+		return
+	}
+	if area < 0 {
+		b, c = c, b
+		dB, dC = dC, dB
+		area = -area
+	}
+
+	// Sample texel centers, which sit half a texel in from their corner
+	last := float64(f.size - 1)
+	minX := max(math.Ceil(min(a[0], b[0], c[0])-.5), 0)
+	maxX := min(math.Floor(max(a[0], b[0], c[0])-.5), last)
+	minY := max(math.Ceil(min(a[1], b[1], c[1])-.5), 0)
+	maxY := min(math.Floor(max(a[1], b[1], c[1])-.5), last)
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			px, py := x+.5, y+.5
+			eA := EdgeFunction(b, c, px, py)
+			eB := EdgeFunction(c, a, px, py)
+			eC := EdgeFunction(a, b, px, py)
+			if eA < 0 || eB < 0 || eC < 0 {
+				continue
+			}
+
+			var depth float64
+			if f.ortho {
+				depth = (eA*dA + eB*dB + eC*dC) / area
+			} else {
+				depth = area / (eA/ca[3] + eB/cb[3] + eC/cc[3])
+			}
+
+			i := int(y)*f.size + int(x)
+			if depth < f.depth[i] {
+				f.depth[i] = depth
+			}
+		}
+	}
+}
+
+// Face of the map which a worldspace point is in view of
+func (m *shadowMap) faceFor(p []float64) *shadowFace {
+	if len(m.faces) != 6 {
+		return m.faces[0]
+	}
+
+	// Point lights use the cube face the point is in
+	dx, dy, dz := p[0]-m.pos[0], p[1]-m.pos[1], p[2]-m.pos[2]
+	ax, ay, az := math.Abs(dx), math.Abs(dy), math.Abs(dz)
+	switch {
+	case ax >= ay && ax >= az && dx >= 0:
+		return m.faces[0]
+	case ax >= ay && ax >= az:
+		return m.faces[1]
+	case ay >= az && dy >= 0:
+		return m.faces[2]
+	case ay >= az:
+		return m.faces[3]
+	case dz >= 0:
+		return m.faces[4]
+	}
+	return m.faces[5]
+}
+
+// World size of a texel of the map at a point, the most a depth read from the
+// map can be off by
+func (m *shadowMap) TexelSize(p []float64) float64 {
+	f := m.faceFor(p)
+	if f.ortho {
+		return max(f.spanU, f.spanV) / float64(f.size)
+	}
+
+	var clip [5]float64
+	f.clipTo(clip[:], p, m.pos)
+	return 2 * max(clip[3], shadowNear) / (f.scale * float64(f.size))
+}
+
+// How much of the light reaches a worldspace point, 0 in shadow and 1 lit
+func (m *shadowMap) Visibility(p []float64) float64 {
+	f := m.faceFor(p)
+
+	var clip [5]float64
+	c := clip[:]
+	f.clipTo(c, p, m.pos)
+	if c[3] <= shadowNear {
+		return 1
+	}
+	x, y, depth := f.texel(c)
+	if x < 0 || y < 0 || x >= float64(f.size) || y >= float64(f.size) {
+		return 1
+	}
+
+	// Allow for the size of a texel, so faces don't shadow themselves
+	texelSize := 2 * c[3] / (f.scale * float64(f.size))
+	if f.ortho {
+		texelSize = max(f.spanU, f.spanV) / float64(f.size)
+	}
+
+	if depth-texelSize > f.depth[int(y)*f.size+int(x)] {
+		return 0
+	}
+	return 1
+}
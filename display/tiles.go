@@ -0,0 +1,133 @@
+package display
+
+import (
+	"go3d/utils"
+	"sync"
+	"sync/atomic"
+)
+
+// Size of a screen tile in pixels
+const (
+	TileWidth  = 32
+	TileHeight = 16
+)
+
+// A region of the screen, with the polygons that overlap it in draw order
+type tile struct {
+	minX, minY, maxX, maxY int
+	polygons               []int
+}
+
+// Split the screen into tiles and record which polygons touch each tile
+func (v *View) BinPolygons(polygons []screenPolygon) []tile {
+	tilesX := (int(v.Xpx) + TileWidth - 1) / TileWidth
+	tilesY := (int(v.Ypx) + TileHeight - 1) / TileHeight
+
+	tiles := make([]tile, tilesX*tilesY)
+	for ty := range tilesY {
+		for tx := range tilesX {
+			tiles[ty*tilesX+tx] = tile{
+				minX: tx * TileWidth,
+				minY: ty * TileHeight,
+				maxX: min((tx+1)*TileWidth, int(v.Xpx)) - 1,
+				maxY: min((ty+1)*TileHeight, int(v.Ypx)) - 1,
+			}
+		}
+	}
+
+	// Add each polygon to every tile its bounding box overlaps, polygons are
+	// visited in order so each tile's list stays in draw order
+	for i, p := range polygons {
+		for ty := p.minY / TileHeight; ty <= p.maxY/TileHeight; ty++ {
+			for tx := p.minX / TileWidth; tx <= p.maxX/TileWidth; tx++ {
+				tiles[ty*tilesX+tx].polygons = append(tiles[ty*tilesX+tx].polygons, i)
+			}
+		}
+	}
+
+	return tiles
+}
+
+// Draw every tile with a pool of workers. Tiles never share pixels and each
+// draws its polygons in order, so the output is the same for any worker count
+func (v *View) RasterizeTiles(tiles []tile, polygons []screenPolygon) {
+	workers := min(v.WorkerCount(), len(tiles))
+
+	// Draw on this goroutine when there is nothing to split
+	if workers <= 1 {
+		for i := range tiles {
+			v.RasterizeTile(&tiles[i], polygons)
+		}
+		return
+	}
+
+	// Workers take the next undrawn tile until none are left
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(tiles) {
+					return
+				}
+				v.RasterizeTile(&tiles[i], polygons)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Draw the faces, wire frame and verts of each polygon within a tile
+func (v *View) RasterizeTile(t *tile, polygons []screenPolygon) {
+	for _, i := range t.polygons {
+		p := &polygons[i]
+
+		// Fill in faces with the edge function rasterizer, one triangle at a
+		// time fanning around the clipped polygon
+		if utils.RenderFace {
+			for j := 1; j < len(p.screenVerts)-1; j++ {
+				v.FillTriangle(p, [3]int{0, j, j + 1}, t)
+			}
+		}
+
+		// Load wire frame on top of the face
+		for _, px := range p.wire {
+			if t.Contains(px) {
+				v.FrameBuffer[px[1]][px[0]] = wirePixel()
+			}
+		}
+
+		// Load vertecies to buffer
+		if v.Wire == WireBraille {
+			continue
+		}
+		for _, vert := range p.verts {
+			if px := v.RasterPoint(vert); t.Contains(px) {
+				v.FrameBuffer[px[1]][px[0]] = vertPixel()
+			}
+		}
+	}
+}
+
+// Pixels of the wire frame and verts, which have their own characters in
+// ASCII output
+func wirePixel() utils.Pixel {
+	p := utils.ColorPixel("Cyan", 5)
+	p.Glyph = AsciiWire
+	return p
+}
+
+func vertPixel() utils.Pixel {
+	p := utils.ColorPixel("Red", 5)
+	p.Glyph = AsciiVert
+	return p
+}
+
+// Check if a pixel is within the tile
+func (t *tile) Contains(px []uint16) bool {
+	x, y := int(px[0]), int(px[1])
+	return x >= t.minX && x <= t.maxX && y >= t.minY && y <= t.maxY
+}
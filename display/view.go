@@ -4,10 +4,10 @@ import (
 	"fmt"
 	"go3d/actors"
 	"go3d/utils"
-	"strings"
-	"syscall"
+	"io"
+	"os"
+	"sync/atomic"
 	"time"
-	"unsafe"
 )
 
 // View defines the screenspace printed to the terminal, with any debug
@@ -16,7 +16,7 @@ type View struct {
 	Xpx         uint16
 	Ypx         uint16
 	TargetFPS   uint8
-	FrameBuffer [][]string
+	FrameBuffer [][]utils.Pixel
 	DepthBuffer [][]float64
 
 	Fov      uint8
@@ -33,11 +33,23 @@ type View struct {
 	CamZ   float64
 	CamRot []float64
 
+	// Sines and cosines of CamRot, and of each object's rotation, for the
+	// current frame
+	camRotation  utils.Rotation
+	objRotations map[*actors.Object]utils.Rotation
+
 	RenderWire    bool
 	OverlayOrigin []uint16
 
+	// Which faces to skip, can be overridden per Object
+	Cull       actors.CullMode
+	CulledTris int
+
 	CamMoveSpeed float64
 
+	// Goroutines used for rendering, 0 uses one per CPU
+	Workers int
+
 	FrameStart time.Time
 	FrameTime  time.Duration
 	FrameEnd   time.Duration
@@ -46,26 +58,102 @@ type View struct {
 	FrameCount   uint64
 	PrevFt       float64
 
-	Xborder     string
-	Triangles   []*actors.Triangle
-	PointLights []*actors.Light
+	Xborder   string
+	Triangles []*actors.Triangle
+	Lights    []actors.LightSource
+
+	// Width and height in texels of each shadow map face, and the maps drawn
+	// for the current frame
+	ShadowMapSize int
+	shadowMaps    []*shadowMap
+
+	// Distance fog, off by default
+	Fog Fog
+
+	// How textures are sampled, nearest texel by default
+	TextureFilter utils.TextureFilter
+
+	// Where DrawBuffer writes frames, the terminal unless headless
+	Out      io.Writer
+	Headless bool
+
+	// How colors are written to the terminal
+	Output OutputMode
+
+	// Terminal area drawn to, and how pixels fill its cells
+	TermCols uint16
+	TermRows uint16
+	Cells    CellMode
+
+	// How the wire frame is drawn, braille dots are kept in their own buffer
+	Wire          WireMode
+	BrailleBuffer [][]BrailleCell
+
+	// Only write cells which changed since the last frame
+	DiffOutput bool
+	prevCells  [][]cell
+
+	// Current GIF recording, and the error from saving the last one
+	Recorder  *Recorder
+	RecordErr error
+
+	// Set by ToggleRecording, applied at the start of a frame
+	recordToggled atomic.Bool
+
+	// Set when the terminal was resized, applied at the start of a frame
+	resized atomic.Bool
 }
 
 // Create a view filling the whole terminal, and accept some custom options
 func CreateView(fps uint8, moveSpeed float64) *View {
 
 	// Get the terminal width and height
-	var ws struct {
-		Rows uint16
-		Cols uint16
-		X    uint16
-		Y    uint16
+	cols, rows, _ := terminalSize()
+
+	// Leave the last row free so the frame never scrolls the terminal
+	v := newView(cols, max(rows, 1)-1, fps, moveSpeed)
+	v.Out = os.Stdout
+
+	// Follow the terminal size as the window is resized
+	v.WatchResize()
+
+	// Use 24-bit color when the terminal supports it, falling back to the
+	// 256 color palette, or plain text for dumb terminals
+	if IsDumbTerminal() {
+		v.Output = OutputASCII
+	} else if SupportsTrueColor() {
+		v.Output = OutputTrueColor
 	}
-	_, _, _ = syscall.Syscall(syscall.SYS_IOCTL, uintptr(0), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+
+	// Remove cursor
+	if v.Output != OutputASCII {
+		fmt.Fprint(v.Out, "\033[?25l")
+	}
+
+	return v
+}
+
+// Create a view which renders to memory without needing a terminal. Frames
+// drawn with DrawBuffer are written to out, which may be nil to only keep the
+// frame in the FrameBuffer. Width and height are in pixels, as for
+// ResizePixels
+func CreateHeadlessView(width uint16, height uint16, fps uint8, moveSpeed float64, out io.Writer) *View {
+	if out == nil {
+		out = io.Discard
+	}
+
+	// Size as if drawing full cells to a terminal just big enough
+	v := newView(width*2, height, fps, moveSpeed)
+	v.Out = out
+	v.Headless = true
+
+	return v
+}
+
+// Shared setup for a view filling a terminal area
+func newView(cols uint16, rows uint16, fps uint8, moveSpeed float64) *View {
 
 	v := View{
-		Xpx:          ws.Cols / 2,
-		Ypx:          ws.Rows - 1,
 		TargetFPS:    fps,
 		Fov:          90,
 		CamX:         0,
@@ -77,6 +165,10 @@ func CreateView(fps uint8, moveSpeed float64) *View {
 		CamMoveSpeed: moveSpeed,
 
 		RenderWire: true,
+		Cull:       actors.CullBack,
+		DiffOutput: true,
+
+		ShadowMapSize: 128,
 	}
 
 	// Calc max frame time
@@ -84,19 +176,9 @@ func CreateView(fps uint8, moveSpeed float64) *View {
 
 	// Origin for drawing big text
 	v.OverlayOrigin = []uint16{5, 5}
-	// Initialize buffers
-	v.FrameBuffer, v.DepthBuffer = utils.CreateBuffers(v.Xpx, v.Ypx)
-
-	// Calculate projection constants
-	v.CalcProjectionConstants()
-
-	// Initialize screen border
-	v.Xborder = utils.ColorMap["Blue"][4] + strings.Repeat(pixel[1], int(v.Xpx)+2) + "\033[0m\n"
-
-	// Remove cursor
-	fmt.Print("\033[?25l")
 
-	v.ClearBuffer()
+	// Initialize buffers and projection for the screen size
+	v.ApplySize(cols, rows)
 
 	return &v
 }
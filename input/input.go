@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go3d/utils"
 	"os"
+	"sync/atomic"
 	"syscall"
 	"time"
 	"unsafe"
@@ -17,6 +18,14 @@ var fd = syscall.Stdin
 var Key string
 var KeyTimeStamp time.Time
 
+// Set when Q is pressed. The main loop should stop on it, and save anything
+// it needs to before calling RestoreTerminal
+var Quit atomic.Bool
+
+// Set when R is pressed, until a View's HandleInput takes it to start or stop
+// recording
+var RecordPressed atomic.Bool
+
 // Save the current key pressed to a global variable
 // TODO: Allow for key chords
 func ListenKeys() {
@@ -41,8 +50,8 @@ func ListenKeys() {
 
 			switch b[0] {
 			case 'q':
-				// Run cleanup and exit program on 'q'
-				RestoreTerminal()
+				// Let the main loop clean up and exit on 'q'
+				Quit.Store(true)
 				// Stop loop
 				break keyRead
 
@@ -62,6 +71,10 @@ func ListenKeys() {
 			case '0':
 				utils.RenderLighting = !utils.RenderLighting
 
+			// Start or stop recording a GIF
+			case 'r', 'R':
+				RecordPressed.Store(true)
+
 			}
 		}
 
@@ -12,13 +12,6 @@ func main() {
 	scene := createDemoSceneWithStatics()
 
 	// Add some dynamic lighting
-	headLights := &actors.Light{
-		LightX:    0,
-		LightY:    -4,
-		LightZ:    -40,
-		Intensity: .8,
-		Falloff:   25,
-	}
 	carLight := &actors.Light{
 		LightX:    0,
 		LightY:    0,
@@ -28,22 +21,52 @@ func main() {
 	}
 
 	// Add some dynamic objects
-	car := actors.CreateObject(utils.ParseObj("./models/car.obj"), 0, -6.5, 45, .1, "Red")
+	car := actors.LoadObject("./models/car.obj", 0, -6.5, 45, .1, "Red")
 	hl1 := actors.CreateObject(utils.ParseObj("./models/headlight.obj"), -1, -5.2, 40.7, .08, "Yellow")
 	hl2 := actors.CreateObject(utils.ParseObj("./models/headlight.obj"), 1, -5.2, 40.7, .08, "Yellow")
 	hl1.Rotate(90, 0, 0)
 
+	// The car has no vertex normals, blend its faces for smooth shading
+	car.SmoothNormals(60)
+	car.Shading = actors.ShadeSmooth
+
+	// Glossy paint, with highlights that move with the camera
+	car.Specular = .6
+
+	// Headlights shining ahead of the car, placed relative to it so they
+	// follow it down the road
+	var headLights []*actors.SpotLight
+	for _, x := range []float64{-1, 1} {
+		headLight := &actors.SpotLight{
+			LightX:     x,
+			LightY:     1.3,
+			LightZ:     4.3,
+			DirX:       0,
+			DirY:       -.15,
+			DirZ:       1,
+			InnerAngle: 15,
+			OuterAngle: 35,
+			Intensity:  .8,
+			Falloff:    25,
+			Color:      &[3]float64{1, .9, .6},
+		}
+		headLight.Attach(car)
+		headLights = append(headLights, headLight)
+	}
+
 	// Add these to the scene too
 	scene.RegisterObject(hl1)
 	scene.RegisterObject(hl2)
-	scene.RegisterLight(headLights)
+	for _, headLight := range headLights {
+		scene.RegisterLight(headLight)
+	}
 	scene.RegisterLight(carLight)
 	scene.RegisterObject(car)
 
 	// Listen to keyboard input
 	input.ListenKeys()
 	// Main demo loop
-	for scene.CamY > 0 {
+	for scene.CamY > 0 && !input.Quit.Load() {
 
 		scene.StartFrame()
 		scene.ClearBuffer()
@@ -55,7 +78,6 @@ func main() {
 		car.Translate(0, 0, .15)
 		hl1.Translate(0, 0, .15)
 		hl2.Translate(0, 0, .15)
-		headLights.Translate(0, 0, .15)
 		carLight.Translate(0, 0, .15)
 		// End Scene logic
 
@@ -67,7 +89,9 @@ func main() {
 		scene.FrameSync("sleep", 0)
 
 	}
-	// Restore terminal settings after demo
+	// Save any recording still running, then restore terminal settings after
+	// the demo ends or is quit
+	scene.StopRecording()
 	input.RestoreTerminal()
 
 }
@@ -1,111 +1,187 @@
 package utils
 
-// Escape codes for colored pixel + luminance
+import "math"
 
-// Dark = 1, Light = 10
-var ColorMap = map[string]map[int]string{
-	"Reset": {0: "\033[0m"},
+// xterm 256 color palette indexes for each color + luminance, encoded to
+// escape codes when the framebuffer is drawn
 
+// Dark = 1, Light = 10
+var ColorMap = map[string]map[int]uint8{
 	"Red": {
-		1:  "\033[38;5;232m██",
-		2:  "\033[38;5;52m██", // Dark red
-		3:  "\033[38;5;88m██",
-		4:  "\033[38;5;124m██",
-		5:  "\033[38;5;160m██",
-		6:  "\033[38;5;196m██", // Standard red
-		7:  "\033[38;5;197m██",
-		8:  "\033[38;5;198m██",
-		9:  "\033[38;5;199m██",
-		10: "\033[38;5;200m██",
-		// 10: "\033[38;5;201m██", // Light red/pink
+		1:  232,
+		2:  52, // Dark red
+		3:  88,
+		4:  124,
+		5:  160,
+		6:  196, // Standard red
+		7:  197,
+		8:  198,
+		9:  199,
+		10: 200,
+		// 10: 201, // Light red/pink
 	},
 	"Green": {
-		1:  "\033[38;5;232m██",
-		2:  "\033[38;5;22m██", // Dark green
-		3:  "\033[38;5;28m██",
-		4:  "\033[38;5;34m██",
-		5:  "\033[38;5;40m██",
-		6:  "\033[38;5;46m██", // Standard green
-		7:  "\033[38;5;47m██",
-		8:  "\033[38;5;48m██",
-		9:  "\033[38;5;49m██",
-		10: "\033[38;5;50m██",
-		// 10: "\033[38;5;51m██", // Light green/cyan
+		1:  232,
+		2:  22, // Dark green
+		3:  28,
+		4:  34,
+		5:  40,
+		6:  46, // Standard green
+		7:  47,
+		8:  48,
+		9:  49,
+		10: 50,
+		// 10: 51, // Light green/cyan
 	},
 	"Blue": {
-		1:  "\033[38;5;232m██",
-		2:  "\033[38;5;17m██", // Dark blue
-		3:  "\033[38;5;18m██",
-		4:  "\033[38;5;19m██",
-		5:  "\033[38;5;20m██",
-		6:  "\033[38;5;21m██", // Standard blue
-		7:  "\033[38;5;27m██",
-		8:  "\033[38;5;33m██",
-		9:  "\033[38;5;39m██",
-		10: "\033[38;5;45m██",
-		// 10: "\033[38;5;51m██", // Light blue/cyan
+		1:  232,
+		2:  17, // Dark blue
+		3:  18,
+		4:  19,
+		5:  20,
+		6:  21, // Standard blue
+		7:  27,
+		8:  33,
+		9:  39,
+		10: 45,
+		// 10: 51, // Light blue/cyan
 	},
 	"Yellow": {
-		1:  "\033[38;5;232m██",
-		2:  "\033[38;5;58m██", // Dark yellow/brown
-		3:  "\033[38;5;94m██",
-		4:  "\033[38;5;136m██",
-		5:  "\033[38;5;178m██",
-		6:  "\033[38;5;220m██", // Standard yellow
-		7:  "\033[38;5;221m██",
-		8:  "\033[38;5;222m██",
-		9:  "\033[38;5;223m██",
-		10: "\033[38;5;224m██",
-		// 10: "\033[38;5;225m██", // Light yellow
+		1:  232,
+		2:  58, // Dark yellow/brown
+		3:  94,
+		4:  136,
+		5:  178,
+		6:  220, // Standard yellow
+		7:  221,
+		8:  222,
+		9:  223,
+		10: 224,
+		// 10: 225, // Light yellow
 	},
 	"Magenta": {
-		1:  "\033[38;5;232m██",
-		2:  "\033[38;5;53m██", // Dark magenta
-		3:  "\033[38;5;89m██",
-		4:  "\033[38;5;125m██",
-		5:  "\033[38;5;161m██",
-		6:  "\033[38;5;197m██", // Standard magenta
-		7:  "\033[38;5;198m██",
-		8:  "\033[38;5;199m██",
-		9:  "\033[38;5;200m██",
-		10: "\033[38;5;201m██",
-		// 10: "\033[38;5;207m██", // Light magenta
+		1:  232,
+		2:  53, // Dark magenta
+		3:  89,
+		4:  125,
+		5:  161,
+		6:  197, // Standard magenta
+		7:  198,
+		8:  199,
+		9:  200,
+		10: 201,
+		// 10: 207, // Light magenta
 	},
 	"Cyan": {
-		1:  "\033[38;5;232m██",
-		2:  "\033[38;5;23m██", // Dark cyan
-		3:  "\033[38;5;30m██",
-		4:  "\033[38;5;37m██",
-		5:  "\033[38;5;44m██",
-		6:  "\033[38;5;51m██", // Standard cyan
-		7:  "\033[38;5;50m██",
-		8:  "\033[38;5;49m██",
-		9:  "\033[38;5;48m██",
-		10: "\033[38;5;47m██",
-		// 10: "\033[38;5;46m██", // Light cyan/green
+		1:  232,
+		2:  23, // Dark cyan
+		3:  30,
+		4:  37,
+		5:  44,
+		6:  51, // Standard cyan
+		7:  50,
+		8:  49,
+		9:  48,
+		10: 47,
+		// 10: 46, // Light cyan/green
 	},
 	"Gray": {
-		1:  "\033[38;5;232m██", // Almost black
-		2:  "\033[38;5;236m██",
-		3:  "\033[38;5;240m██",
-		4:  "\033[38;5;244m██",
-		5:  "\033[38;5;248m██", // Medium gray
-		6:  "\033[38;5;252m██",
-		7:  "\033[38;5;253m██",
-		8:  "\033[38;5;254m██",
-		9:  "\033[38;5;255m██",
-		10: "\033[38;5;231m██", // Light gray
+		1:  232, // Almost black
+		2:  236,
+		3:  240,
+		4:  244,
+		5:  248, // Medium gray
+		6:  252,
+		7:  253,
+		8:  254,
+		9:  255,
+		10: 231, // Light gray
 	},
 	"White": {
-		1:  "\033[38;5;231m██",
-		2:  "\033[38;5;231m██",
-		3:  "\033[38;5;231m██",
-		4:  "\033[38;5;231m██",
-		5:  "\033[38;5;231m██",
-		6:  "\033[38;5;231m██",
-		7:  "\033[38;5;231m██",
-		8:  "\033[38;5;231m██",
-		9:  "\033[38;5;231m██",
-		10: "\033[38;5;231m██",
+		1:  231,
+		2:  231,
+		3:  231,
+		4:  231,
+		5:  231,
+		6:  231,
+		7:  231,
+		8:  231,
+		9:  231,
+		10: 231,
 	},
 }
+
+// Create a framebuffer pixel from a color name and luminance
+func ColorPixel(color string, shade int) Pixel {
+	index := ColorMap[color][shade]
+	return Pixel{
+		Filled: true,
+		Color:  index,
+		Shade:  uint8(shade),
+		RGB:    XtermRGB(index),
+	}
+}
+
+// A base color with its palette ramp looked up ahead of time, for shading
+// the same color many times such as once per pixel
+type Shader struct {
+	base   [3]uint8
+	ramp   [11]uint8
+	custom bool
+}
+
+// Prepare a named color, or a custom base RGB if given, for shading
+func NewShader(color string, rgb *[3]uint8) Shader {
+	ramp := ColorMap[color]
+
+	s := Shader{base: XtermRGB(ramp[6])}
+	if rgb != nil {
+		s.base = *rgb
+		s.custom = true
+	}
+	for level := 1; level <= 10; level++ {
+		s.ramp[level] = ramp[level]
+	}
+	return s
+}
+
+// Prepare an RGB color for shading, with no named color's ramp. Cheap enough
+// to make for every pixel, such as for each texel of a texture
+func RGBShader(rgb [3]uint8) Shader {
+	return Shader{base: rgb, custom: true}
+}
+
+// Shade the color by a continuous luminance between 1-10
+func (s *Shader) Pixel(shade float64) Pixel {
+	level := min(max(int(math.Round(shade)), 1), 10)
+	shaded := ShadeRGB(s.base, shade)
+
+	// Custom colors have no ramp, use the closest palette color instead
+	index := s.ramp[level]
+	if s.custom {
+		index = NearestXterm(shaded)
+	}
+
+	return Pixel{
+		Filled: true,
+		Color:  index,
+		Shade:  uint8(level),
+		RGB:    shaded,
+	}
+}
+
+// Shade the color by a luminance between 1-10 under colored light, with the
+// light's color between 0-1 multiplying the base color. White light keeps
+// the named color's ramp, other colors use the closest palette color
+func (s *Shader) Tinted(shade float64, tint [3]float64) Pixel {
+	if min(tint[0], tint[1], tint[2]) > .999 {
+		return s.Pixel(shade)
+	}
+
+	tinted := *s
+	for i, c := range s.base {
+		tinted.base[i] = uint8(float64(c) * min(max(tint[i], 0), 1))
+	}
+	tinted.custom = true
+	return tinted.Pixel(shade)
+}
@@ -0,0 +1,87 @@
+package utils
+
+import "sync"
+
+// RGB values of the 16 system colors, using xterm's defaults
+var systemColors = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// Channel levels of the 6x6x6 color cube
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// Convert an xterm 256 color palette index to RGB
+func XtermRGB(index uint8) [3]uint8 {
+	switch {
+	case index < 16:
+		return systemColors[index]
+	case index < 232:
+		// 6x6x6 color cube
+		i := index - 16
+		return [3]uint8{cubeLevels[i/36], cubeLevels[(i/6)%6], cubeLevels[i%6]}
+	default:
+		// 24 step grayscale ramp
+		g := 8 + 10*(index-232)
+		return [3]uint8{g, g, g}
+	}
+}
+
+// Nearest palette index for every RGB color, with channels reduced to 5 bits
+var nearestTable [32 * 32 * 32]uint8
+var nearestOnce sync.Once
+
+// Find the closest xterm 256 color to an RGB value. System colors are skipped
+// as terminal themes often change them
+func NearestXterm(rgb [3]uint8) uint8 {
+	nearestOnce.Do(buildNearestTable)
+	return nearestTable[int(rgb[0]>>3)<<10|int(rgb[1]>>3)<<5|int(rgb[2]>>3)]
+}
+
+// Precompute NearestXterm so lookups are cheap enough to do per pixel
+func buildNearestTable() {
+	for r := range 32 {
+		for g := range 32 {
+			for b := range 32 {
+				// Center of the 5 bit bucket
+				target := [3]int{r<<3 | 4, g<<3 | 4, b<<3 | 4}
+
+				best, bestDist := 16, -1
+				for i := 16; i < 256; i++ {
+					c := XtermRGB(uint8(i))
+					dr := int(c[0]) - target[0]
+					dg := int(c[1]) - target[1]
+					db := int(c[2]) - target[2]
+
+					// Weight channels by how sensitive eyes are to them
+					dist := 3*dr*dr + 4*dg*dg + 2*db*db
+					if bestDist < 0 || dist < bestDist {
+						best, bestDist = i, dist
+					}
+				}
+				nearestTable[r<<10|g<<5|b] = uint8(best)
+			}
+		}
+	}
+}
+
+// Shade an RGB color by a luminance between 1-10, matching the ColorMap ramps.
+// 6 is the base color, lower fades to black and higher fades towards white
+func ShadeRGB(base [3]uint8, shade float64) [3]uint8 {
+	shade = min(max(shade, 1), 10)
+
+	var out [3]uint8
+	for i, c := range base {
+		var f float64
+		if shade <= 6 {
+			// 1 is near black, matching the darkest ramp entry
+			f = 8 + (float64(c)-8)*(shade-1)/5
+		} else {
+			f = float64(c) + (255-float64(c))*.6*(shade-6)/4
+		}
+		out[i] = uint8(min(max(f, 0), 255))
+	}
+	return out
+}
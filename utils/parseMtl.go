@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A material from an .mtl file. Colors are RGB between 0-1, nil if the
+// material didn't set them
+type ObjMaterial struct {
+	Name string
+
+	Diffuse  *[3]float64 // Kd
+	Ambient  *[3]float64 // Ka
+	Specular *[3]float64 // Ks
+
+	Shininess float64 // Ns, the specular exponent
+	Opacity   float64 // d, or 1 - Tr. 1 is opaque
+
+	// Path of the map_Kd image, relative to the working directory
+	DiffuseMap string
+}
+
+// Parse .mtl file into its materials by name. Returns nil if the file can't
+// be read
+func ParseMtl(path string) map[string]*ObjMaterial {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	materials := map[string]*ObjMaterial{}
+	var cur *ObjMaterial
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		// Skip blanks
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Start of a new material, everything after belongs to it
+		if fields[0] == "newmtl" && len(fields) >= 2 {
+			cur = &ObjMaterial{Name: strings.Join(fields[1:], " "), Opacity: 1}
+			materials[cur.Name] = cur
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+
+		switch fields[0] {
+		case "Kd", "Ka", "Ks":
+			if len(fields) < 4 {
+				continue
+			}
+			vec, err := parseVec3(fields[1:4])
+			if err != nil {
+				continue
+			}
+			color := (*[3]float64)(vec)
+			switch fields[0] {
+			case "Kd":
+				cur.Diffuse = color
+			case "Ka":
+				cur.Ambient = color
+			case "Ks":
+				cur.Specular = color
+			}
+		case "Ns":
+			if len(fields) >= 2 {
+				if ns, err := strconv.ParseFloat(fields[1], 64); err == nil {
+					cur.Shininess = ns
+				}
+			}
+		case "map_Kd":
+			if len(fields) >= 2 {
+				cur.DiffuseMap = filepath.Join(filepath.Dir(path), mapPath(fields[1:]))
+			}
+		case "d", "Tr":
+			if len(fields) >= 2 {
+				if d, err := strconv.ParseFloat(fields[1], 64); err == nil {
+					// Tr is the inverse of d
+					if fields[0] == "Tr" {
+						d = 1 - d
+					}
+					cur.Opacity = min(max(d, 0), 1)
+				}
+			}
+		}
+	}
+	return materials
+}
+
+// Load the material libraries named by an .obj mtllib line, which are
+// relative to the .obj file. Names may hold spaces, so the whole line is
+// tried as one file before splitting it into several
+func loadMtlLibs(objPath string, line string, materials map[string]*ObjMaterial) {
+	dir := filepath.Dir(objPath)
+	names := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "mtllib"))
+
+	if lib := ParseMtl(filepath.Join(dir, names)); lib != nil {
+		for name, m := range lib {
+			materials[name] = m
+		}
+		return
+	}
+
+	for _, name := range strings.Fields(names) {
+		for name, m := range ParseMtl(filepath.Join(dir, name)) {
+			materials[name] = m
+		}
+	}
+}
+
+// File name of a texture map line. Options such as -s 1 1 1 come before the
+// name, in which case the name is the last field. Otherwise the whole line is
+// the name, which may hold spaces
+func mapPath(fields []string) string {
+	if strings.HasPrefix(fields[0], "-") {
+		return fields[len(fields)-1]
+	}
+	return strings.Join(fields, " ")
+}
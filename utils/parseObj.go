@@ -7,8 +7,33 @@ import (
 	"strings"
 )
 
+// A triangle from an .obj file. Normals and UVs are nil if the face didn't
+// list one for every vert, and Material is nil if the face has no material or
+// its material library couldn't be loaded
+type ObjTriangle struct {
+	Verts    [][]float64
+	Normals  [][]float64
+	UVs      [][]float64
+	Material *ObjMaterial
+}
+
 // Parse .obj file to convert to Object actor which is made up of Triangles
 func ParseObj(path string) [][][]float64 {
+	objTris := ParseObjTriangles(path)
+	if objTris == nil {
+		return nil
+	}
+
+	tris := [][][]float64{}
+	for _, t := range objTris {
+		tris = append(tris, t.Verts)
+	}
+	return tris
+}
+
+// Parse .obj file into triangles, keeping the vertex normals, texture
+// coordinates and material of each face
+func ParseObjTriangles(path string) []ObjTriangle {
 	// Open file
 	file, err := os.Open(path)
 	if err != nil {
@@ -16,9 +41,17 @@ func ParseObj(path string) [][][]float64 {
 	}
 	defer file.Close()
 
-	// Will store list of vertecies which will be mapped to faces
+	// Will store list of vertecies, normals and UVs which will be mapped to
+	// faces
 	verts := [][]float64{}
-	tris := [][][]float64{}
+	normals := [][]float64{}
+	uvs := [][]float64{}
+	tris := []ObjTriangle{}
+
+	// Materials from every library the file uses, and the one faces are
+	// currently using
+	materials := map[string]*ObjMaterial{}
+	var material *ObjMaterial
 
 	scanner := bufio.NewScanner(file)
 
@@ -35,23 +68,44 @@ func ParseObj(path string) [][][]float64 {
 		// Vertex line
 		if fields[0] == "v" && len(fields) >= 4 {
 			// Parse the X, Y, Z coordinates
-			x, err := strconv.ParseFloat(fields[1], 64)
+			vert, err := parseVec3(fields[1:4])
 			if err != nil {
 				return nil
 			}
 
-			y, err := strconv.ParseFloat(fields[2], 64)
+			// Add to slice of vertecies
+			verts = append(verts, vert)
+		}
+
+		// Vertex normal line
+		if fields[0] == "vn" && len(fields) >= 4 {
+			normal, err := parseVec3(fields[1:4])
 			if err != nil {
 				return nil
 			}
+			normals = append(normals, Normalize(normal))
+		}
 
-			z, err := strconv.ParseFloat(fields[3], 64)
-			if err != nil {
-				return nil
+		// Texture coordinate line, v is optional and 0 if left out, the
+		// optional depth is ignored
+		if fields[0] == "vt" && len(fields) >= 2 {
+			uv := []float64{0, 0}
+			for i := range min(len(fields)-1, 2) {
+				c, err := strconv.ParseFloat(fields[i+1], 64)
+				if err != nil {
+					return nil
+				}
+				uv[i] = c
 			}
+			uvs = append(uvs, uv)
+		}
 
-			// Add to slice of vertecies
-			verts = append(verts, []float64{x, y, z})
+		// Material lines, faces use the last material named before them
+		if fields[0] == "mtllib" && len(fields) >= 2 {
+			loadMtlLibs(path, line, materials)
+		}
+		if fields[0] == "usemtl" {
+			material = materials[strings.Join(fields[1:], " ")]
 		}
 
 		// Face lines
@@ -59,19 +113,48 @@ func ParseObj(path string) [][][]float64 {
 
 			// Works for 3-n verts, will subdivide face into triangles
 			if len(fields) >= 4 {
-				// Collect all verts
+				// Collect all verts, and normals and UVs if every vert has
+				// one
 				vs := []int{}
+				ns := []int{}
+				ts := []int{}
 				for i := 1; i < len(fields); i++ {
-					vertexStr := strings.Split(fields[i], "/")[0]
-					vertexInt, _ := strconv.Atoi(vertexStr)
+					// Face verts are vert/uv/normal, with the last 2 optional
+					indices := strings.Split(fields[i], "/")
+
+					vertexInt, _ := strconv.Atoi(indices[0])
 					vertexInt-- //0 index
 					vs = append(vs, vertexInt)
+
+					if len(indices) >= 2 {
+						uvInt, err := strconv.Atoi(indices[1])
+						if err == nil && uvInt >= 1 && uvInt <= len(uvs) {
+							ts = append(ts, uvInt-1)
+						}
+					}
+					if len(indices) >= 3 {
+						normalInt, err := strconv.Atoi(indices[2])
+						if err == nil && normalInt >= 1 && normalInt <= len(normals) {
+							ns = append(ns, normalInt-1)
+						}
+					}
 				}
+				hasNormals := len(ns) == len(vs)
+				hasUVs := len(ts) == len(vs)
 
 				// Create triangles - .obj face traces face counter clockwise
 				// First vert will be origin for all triangles, create triangles going around the face
 				for i := 1; i < len(vs)-1; i++ {
-					triangle := [][]float64{verts[vs[0]], verts[vs[i]], verts[vs[i+1]]}
+					triangle := ObjTriangle{
+						Verts:    [][]float64{verts[vs[0]], verts[vs[i]], verts[vs[i+1]]},
+						Material: material,
+					}
+					if hasNormals {
+						triangle.Normals = [][]float64{normals[ns[0]], normals[ns[i]], normals[ns[i+1]]}
+					}
+					if hasUVs {
+						triangle.UVs = [][]float64{uvs[ts[0]], uvs[ts[i]], uvs[ts[i+1]]}
+					}
 					tris = append(tris, triangle)
 
 				}
@@ -81,3 +164,16 @@ func ParseObj(path string) [][][]float64 {
 	}
 	return tris
 }
+
+// Parse 3 fields of a line as a vector
+func parseVec3(fields []string) ([]float64, error) {
+	vec := make([]float64, 3)
+	for i, f := range fields {
+		val, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, err
+		}
+		vec[i] = val
+	}
+	return vec, nil
+}
@@ -0,0 +1,12 @@
+package utils
+
+// A single Goren pixel in the framebuffer. Colors are kept as values so frames
+// can be compared, exported or re-encoded, and are only turned into escape
+// codes when drawn to the terminal
+type Pixel struct {
+	Filled bool     // Empty pixels are drawn as blank space
+	Color  uint8    // xterm 256 color palette index
+	Shade  uint8    // Luminance of the pixel 1-10
+	RGB    [3]uint8 // Exact color for truecolor output
+	Glyph  byte     // Character for ASCII output, 0 to pick one by Shade
+}
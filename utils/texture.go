@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// How a texture is sampled between its texels
+type TextureFilter uint8
+
+const (
+	FilterNearest  TextureFilter = iota // Closest texel, keeps hard pixel edges
+	FilterBilinear                      // Mix of the 4 closest texels, smoother up close
+)
+
+// An RGB image mapped onto faces by their UV coordinates
+type Texture struct {
+	Width  int
+	Height int
+
+	// Texels row by row from the top left
+	Pix [][3]uint8
+}
+
+// Load a PNG or binary/plain PPM image as a texture
+func LoadTexture(path string) (*Texture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	magic, err := r.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	if magic[0] == 'P' && (magic[1] == '6' || magic[1] == '3') {
+		return DecodePPM(r)
+	}
+
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return TextureFromImage(img), nil
+}
+
+// Copy an image into a texture, dropping transparency
+func TextureFromImage(img image.Image) *Texture {
+	b := img.Bounds()
+	t := &Texture{Width: b.Dx(), Height: b.Dy(), Pix: make([][3]uint8, b.Dx()*b.Dy())}
+
+	for y := range t.Height {
+		for x := range t.Width {
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			t.Pix[y*t.Width+x] = [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8)}
+		}
+	}
+	return t
+}
+
+// Read a PPM image, either binary (P6) or plain text (P3). Values above 255
+// are scaled down to 8 bits
+func DecodePPM(r io.Reader) (*Texture, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := ppmToken(br)
+	if err != nil {
+		return nil, err
+	}
+	if magic != "P6" && magic != "P3" {
+		return nil, errors.New("ppm: unsupported format " + magic)
+	}
+
+	// Width, height and the largest value
+	var header [3]int
+	for i := range header {
+		tok, err := ppmToken(br)
+		if err != nil {
+			return nil, err
+		}
+		header[i], err = strconv.Atoi(tok)
+		if err != nil || header[i] <= 0 {
+			return nil, errors.New("ppm: bad header")
+		}
+	}
+	width, height, maxVal := header[0], header[1], header[2]
+	if maxVal > 65535 {
+		return nil, errors.New("ppm: bad max value")
+	}
+
+	t := &Texture{Width: width, Height: height, Pix: make([][3]uint8, width*height)}
+	scale := 255 / float64(maxVal)
+
+	for i := range t.Pix {
+		for c := range 3 {
+			var val int
+			switch {
+			case magic == "P3":
+				tok, err := ppmToken(br)
+				if err != nil {
+					return nil, err
+				}
+				if val, err = strconv.Atoi(tok); err != nil {
+					return nil, errors.New("ppm: bad value " + tok)
+				}
+
+			// Binary values are 2 bytes, big endian, above 255
+			case maxVal > 255:
+				var b [2]byte
+				if _, err := io.ReadFull(br, b[:]); err != nil {
+					return nil, err
+				}
+				val = int(b[0])<<8 | int(b[1])
+
+			default:
+				b, err := br.ReadByte()
+				if err != nil {
+					return nil, err
+				}
+				val = int(b)
+			}
+			t.Pix[i][c] = uint8(math.Round(float64(min(max(val, 0), maxVal)) * scale))
+		}
+	}
+	return t, nil
+}
+
+// Next whitespace separated token of a PPM header or plain text body,
+// skipping # comments. A single whitespace byte after the token is consumed,
+// which is where binary data starts after the header
+func ppmToken(br *bufio.Reader) (string, error) {
+	var sb strings.Builder
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			if err == io.EOF && sb.Len() > 0 {
+				return sb.String(), nil
+			}
+			return "", err
+		}
+
+		switch {
+		case b == '#' && sb.Len() == 0:
+			if _, err := br.ReadString('\n'); err != nil {
+				return "", err
+			}
+		case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+			if sb.Len() > 0 {
+				return sb.String(), nil
+			}
+		default:
+			sb.WriteByte(b)
+		}
+	}
+}
+
+// Color of the texture at a UV coordinate. UVs start at the bottom left and
+// repeat outside of 0-1
+func (t *Texture) Sample(u float64, v float64, filter TextureFilter) [3]uint8 {
+	// Texel space, with texel centers at whole numbers
+	x := u*float64(t.Width) - .5
+	y := (1-v)*float64(t.Height) - .5
+
+	if filter == FilterNearest {
+		return t.texel(int(math.Round(x)), int(math.Round(y)))
+	}
+
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+
+	a, b := t.texel(ix, iy), t.texel(ix+1, iy)
+	c, d := t.texel(ix, iy+1), t.texel(ix+1, iy+1)
+
+	var out [3]uint8
+	for i := range out {
+		top := float64(a[i]) + (float64(b[i])-float64(a[i]))*fx
+		bottom := float64(c[i]) + (float64(d[i])-float64(c[i]))*fx
+		out[i] = uint8(math.Round(top + (bottom-top)*fy))
+	}
+	return out
+}
+
+// Texel at a position, wrapping around the edges
+func (t *Texture) texel(x int, y int) [3]uint8 {
+	x %= t.Width
+	if x < 0 {
+		x += t.Width
+	}
+	y %= t.Height
+	if y < 0 {
+		y += t.Height
+	}
+	return t.Pix[y*t.Width+x]
+}
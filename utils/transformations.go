@@ -62,11 +62,45 @@ func RotateVecXYZ(vec []float64, rot []float64) []float64 {
 	return x
 }
 
+// Sines and cosines of a rotation in degrees, worked out once for rotating
+// many vectors by it
+type Rotation struct {
+	sinX, cosX float64
+	sinY, cosY float64
+	sinZ, cosZ float64
+}
+
+func NewRotation(rot []float64) Rotation {
+	xRot, yRot, zRot := DegToRad(rot[0]), DegToRad(rot[1]), DegToRad(rot[2])
+	return Rotation{
+		sinX: math.Sin(xRot), cosX: math.Cos(xRot),
+		sinY: math.Sin(yRot), cosY: math.Cos(yRot),
+		sinZ: math.Sin(zRot), cosZ: math.Cos(zRot),
+	}
+}
+
+// Rotate a 3D vector by Z -> Y -> X, same as RotateVecXYZ
+func (r Rotation) Apply(vec []float64) []float64 {
+	x, y, z := vec[0], vec[1], vec[2]
+
+	x, y = r.cosZ*x-r.sinZ*y, r.sinZ*x+r.cosZ*y
+	x, z = r.cosY*x+r.sinY*z, -r.sinY*x+r.cosY*z
+	y, z = r.cosX*y-r.sinX*z, r.sinX*y+r.cosX*z
+
+	return []float64{x, y, z}
+}
+
 // Transform from object space to world space
 func ApplyWorldMatrix(vert []float64, objX float64, objY float64, objZ float64, objScale float64, objRot []float64) []float64 {
+	return ApplyWorldRotation(vert, objX, objY, objZ, objScale, NewRotation(objRot))
+}
+
+// Transform from object space to world space, with the object's rotation
+// worked out ahead of time
+func ApplyWorldRotation(vert []float64, objX float64, objY float64, objZ float64, objScale float64, objRot Rotation) []float64 {
 
 	// Rotate
-	rotated := RotateVecXYZ(vert, objRot)
+	rotated := objRot.Apply(vert)
 	//Scale
 	sX := rotated[0] * objScale
 	sY := rotated[1] * objScale
@@ -81,15 +115,12 @@ func ApplyWorldMatrix(vert []float64, objX float64, objY float64, objZ float64,
 }
 
 // Apply camera translation and rotation to a vector
-func ApplyCamMatrix(camX float64, camY float64, camZ float64, camRot []float64, x float64, y float64, z float64) []float64 {
+func ApplyCamMatrix(camX float64, camY float64, camZ float64, camRot Rotation, x float64, y float64, z float64) []float64 {
 	// Translate
 	translatedVec := []float64{x - camX, y - camY, z - camZ}
 
 	// Rotate
-	r := RotateVecXYZ(translatedVec, camRot)
-
-	return r
-
+	return camRot.Apply(translatedVec)
 }
 
 // Project vector from camspace to clip space
@@ -22,12 +22,12 @@ func DegToRad(deg float64) float64 {
 }
 
 // Initialize frame buffer and depth buffer
-func CreateBuffers(x uint16, y uint16) ([][]string, [][]float64) {
+func CreateBuffers(x uint16, y uint16) ([][]Pixel, [][]float64) {
 
-	fb := make([][]string, y)
+	fb := make([][]Pixel, y)
 	db := make([][]float64, y)
 	for i := range fb {
-		fb[i] = make([]string, x)
+		fb[i] = make([]Pixel, x)
 		db[i] = make([]float64, x)
 	}
 	return fb, db
@@ -0,0 +1,41 @@
+package utils
+
+import "math"
+
+// Difference of two 3D vectors, a - b
+func SubVec(a []float64, b []float64) []float64 {
+	return []float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+// Dot product of two 3D vectors
+func Dot(a []float64, b []float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+// Cross product of two 3D vectors
+func Cross(a []float64, b []float64) []float64 {
+	return []float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+// Length of a 3D vector
+func Length(a []float64) float64 {
+	return math.Sqrt(Dot(a, a))
+}
+
+// Scale a 3D vector to a length of 1, zero vectors are left as is
+func Normalize(a []float64) []float64 {
+	l := Length(a)
+	if l == 0 {
+		return []float64{a[0], a[1], a[2]}
+	}
+	return []float64{a[0] / l, a[1] / l, a[2] / l}
+}
+
+// Unit normal of a counter clockwise triangle
+func FaceNormal(a []float64, b []float64, c []float64) []float64 {
+	return Normalize(Cross(SubVec(b, a), SubVec(c, a)))
+}